@@ -0,0 +1,15 @@
+data "atuin_activity" "test" {
+  username = atuin_user.test.full_username
+  password = atuin_user.test.password
+  focus    = "month"
+  year     = 2024
+}
+
+output "commands_per_month" {
+  value = { for p in data.atuin_activity.test.periods : p.period => p.count }
+}
+
+# Without credentials, the data source uses the login of the provider auth block
+data "atuin_activity" "provider_user" {
+  focus = "year"
+}
@@ -0,0 +1,9 @@
+data "atuin_store_verification" "test" {
+  username   = atuin_user.test.full_username
+  password   = atuin_user.test.password
+  base64_key = atuin_user.test.base64_key
+}
+
+output "store_ok" {
+  value = data.atuin_store_verification.test.ok
+}
@@ -8,3 +8,54 @@ output "encryption_key" {
   sensitive = true
   value     = atuin_user.test.base64_key
 }
+
+# With username_prefix = "repo-" and email_template = "{{username}}@atuin.corp.example" set on the
+# provider, this creates the user repo-rincewind with email repo-rincewind@atuin.corp.example
+resource "atuin_user" "conventions" {
+  username = "rincewind"
+  password = "swordfish"
+}
+
+# A write-only password is never stored in state. Terraform cannot change it: change it with the
+# Atuin CLI, and then update password_wo and bump password_wo_version.
+# Passwords weaker than min_password_strength fail at terraform validate.
+resource "atuin_user" "write_only" {
+  username              = "vetinari"
+  email                 = "vetinari@discworld.co.uk"
+  password_wo           = var.vetinari_password
+  password_wo_version   = 1
+  min_password_strength = 3
+}
+
+# Changing key_version generates a new encryption key and re-encrypts the history of the user
+resource "atuin_user" "rotated" {
+  username    = "carrot"
+  email       = "carrot@discworld.co.uk"
+  password    = "swordfish"
+  key_version = 2
+
+  # Re-encrypting a long history takes a while. Operations time out after 20 minutes by default.
+  timeouts {
+    update = "1h"
+  }
+}
+
+# Without a password, one is generated. Changing password_keepers generates a new one.
+resource "atuin_user" "generated" {
+  username = "detritus"
+  email    = "detritus@discworld.co.uk"
+
+  password_generation = {
+    length  = 24
+    special = false
+  }
+
+  password_keepers = {
+    rotated_at = "2026-10"
+  }
+}
+
+output "generated_password" {
+  sensitive = true
+  value     = atuin_user.generated.password
+}
@@ -0,0 +1,74 @@
+package atuin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// CalendarFocus is the granularity of the activity calendar.
+type CalendarFocus string
+
+const (
+	// CalendarFocusYear returns the activity per year.
+	CalendarFocusYear CalendarFocus = "year"
+	// CalendarFocusMonth returns the activity per month of a year.
+	CalendarFocusMonth CalendarFocus = "month"
+	// CalendarFocusDay returns the activity per day of a month.
+	CalendarFocusDay CalendarFocus = "day"
+)
+
+var CalendarFocuses = []CalendarFocus{CalendarFocusYear, CalendarFocusMonth, CalendarFocusDay}
+
+// TimePeriodInfo is the activity within a single period of the calendar.
+type TimePeriodInfo struct {
+	Period uint64 `json:"-"`
+	Count  uint64 `json:"count"`
+	Hash   string `json:"hash"`
+}
+
+// Calendar returns the number of history entries per period, sorted by period. The year is used for
+// the month and day focus, the month only for the day focus.
+func (c *AtuinClient) Calendar(ctx context.Context, sessionToken string, focus CalendarFocus, year, month int) ([]TimePeriodInfo, error) {
+	query := url.Values{}
+	query.Set("year", strconv.Itoa(year))
+	query.Set("month", strconv.Itoa(month))
+
+	request, err := c.newAuthorizedRequest(ctx, "GET", "/sync/calendar/"+url.PathEscape(string(focus))+"?"+query.Encode(), sessionToken, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error getting activity calendar: %w", responseError(resp))
+	}
+
+	var periods map[string]TimePeriodInfo
+	err = json.NewDecoder(resp.Body).Decode(&periods)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := make([]TimePeriodInfo, 0, len(periods))
+	for key, info := range periods {
+		info.Period, err = strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar period %q", key)
+		}
+		calendar = append(calendar, info)
+	}
+
+	sort.Slice(calendar, func(i, j int) bool { return calendar[i].Period < calendar[j].Period })
+
+	return calendar, nil
+}
@@ -0,0 +1,29 @@
+package atuin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sync/calendar/month", r.URL.Path)
+		assert.Equal(t, "2024", r.URL.Query().Get("year"))
+		assert.Equal(t, "Token token", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`{"3": {"count": 42, "hash": "b"}, "1": {"count": 7, "hash": "a"}}`))
+	}))
+	defer server.Close()
+
+	client := NewAtuinClient(server.URL)
+
+	calendar, err := client.Calendar(t.Context(), "token", CalendarFocusMonth, 2024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []TimePeriodInfo{{Period: 1, Count: 7, Hash: "a"}, {Period: 3, Count: 42, Hash: "b"}}, calendar)
+}
@@ -2,29 +2,95 @@ package atuin
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	b64 "encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/tyler-smith/go-bip39"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 const API_ENDPOINT = "https://api.atuin.sh"
 
+// Default client-side rate limit, chosen to stay well below the limits of api.atuin.sh
+// when Terraform runs many resource operations in parallel.
+const (
+	DefaultRequestsPerSecond = 5.0
+	DefaultBurst             = 10
+)
+
+const tracerName = "terraform-provider-atuin/internal/atuin_client"
+
 type AtuinClient struct {
-	client *http.Client
-	host   string
+	client        *http.Client
+	baseURL       *url.URL
+	limiter       *rate.Limiter
+	tracer        trace.Tracer
+	sessionToken  string
+	encryptionKey string
+}
+
+// Option configures optional behaviour of an AtuinClient.
+type Option func(*AtuinClient)
+
+// WithRateLimit sets the token-bucket rate limit that is applied to every request made by the client.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *AtuinClient) {
+		c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
+
+// WithTracerProvider sets the OpenTelemetry tracer provider used to trace every request. Without it,
+// the global tracer provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *AtuinClient) {
+		c.tracer = tp.Tracer(tracerName)
+	}
 }
 
-func NewAtuinClient(host string) *AtuinClient {
-	return &AtuinClient{
-		client: &http.Client{},
-		host:   host,
+// WithSessionToken authenticates the client with the session token of an existing login.
+func WithSessionToken(sessionToken string) Option {
+	return func(c *AtuinClient) {
+		c.sessionToken = sessionToken
 	}
 }
 
+// WithEncryptionKey sets the base64 encoded encryption key of the user the client is authenticated as.
+func WithEncryptionKey(key string) Option {
+	return func(c *AtuinClient) {
+		c.encryptionKey = key
+	}
+}
+
+func NewAtuinClient(host string, opts ...Option) *AtuinClient {
+	c := &AtuinClient{
+		client:  &http.Client{},
+		baseURL: parseBaseURL(host),
+		limiter: rate.NewLimiter(rate.Limit(DefaultRequestsPerSecond), DefaultBurst),
+		tracer:  otel.Tracer(tracerName),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
 type Session struct {
 	Session string `json:"session"`
 }
@@ -33,17 +99,192 @@ type ErrorMessage struct {
 	Reason string `json:"reason"`
 }
 
+// APIError is an error response of the Atuin API.
+type APIError struct {
+	StatusCode int
+	// Reason is the reason reported by the server, or the response status if it did not report one.
+	Reason string
+}
+
+func (e *APIError) Error() string {
+	return e.Reason
+}
+
+// IsUnauthorized reports whether the error is a 401 Unauthorized response, which the server returns
+// when logging in with an incorrect password.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// Authenticate logs in, and keeps the session token for operations on the data of the user.
+func (c *AtuinClient) Authenticate(ctx context.Context, username, password string) error {
+	sessionToken, err := c.Login(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	c.sessionToken = sessionToken
+	return nil
+}
+
+// SessionToken returns the session token the client was authenticated with, or an empty string.
+func (c *AtuinClient) SessionToken() string {
+	return c.sessionToken
+}
+
+// EncryptionKey returns the base64 encoded encryption key the client was configured with, or an empty string.
+func (c *AtuinClient) EncryptionKey() string {
+	return c.encryptionKey
+}
+
+// Maximum lengths of the username and email of an Atuin user, in characters, as stored by the Atuin server.
+const (
+	MaxUsernameLength = 32
+	MaxEmailLength    = 128
+)
+
+// ValidateUsername checks a username against the rules of the Atuin server, which only accepts
+// alphanumeric characters, dashes and underscores, up to MaxUsernameLength characters.
+func ValidateUsername(username string) error {
+	if username == "" {
+		return errors.New("the username must not be empty")
+	}
+
+	if utf8.RuneCountInString(username) > MaxUsernameLength {
+		return fmt.Errorf("the username %q must not be longer than %d characters", username, MaxUsernameLength)
+	}
+
+	for _, c := range username {
+		if !unicode.IsLetter(c) && !unicode.IsNumber(c) && c != '-' && c != '_' {
+			return fmt.Errorf("the username %q may only contain alphanumeric characters, dashes and underscores", username)
+		}
+	}
+
+	return nil
+}
+
+// ParseHost parses and normalizes the URL of an Atuin server. The URL must be absolute, with an http or
+// https scheme and a host, and may have a base path when the server runs behind a reverse proxy. The
+// normalized URL has a lowercase scheme and host, and no trailing slash.
+func ParseHost(host string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(host))
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("the URL must start with http:// or https://, got %q", host)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("the URL must include a host, got %q", host)
+	}
+
+	if u.User != nil {
+		return nil, errors.New("the URL must not include credentials")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("the URL must not include a query or fragment, got %q", host)
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	return u, nil
+}
+
+// parseBaseURL parses the host the client was created with. Unlike ParseHost it does not validate the
+// URL, so that an invalid host fails with an error from the first request instead of a panic.
+func parseBaseURL(host string) *url.URL {
+	u, err := url.Parse(strings.TrimRight(host, "/"))
+	if err != nil {
+		return &url.URL{}
+	}
+	return u
+}
+
+// newRequest creates a request for an endpoint of the Atuin API. The endpoint is a path, optionally with
+// a query, relative to the base path of the host.
+func (c *AtuinClient) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	ref, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.baseURL.JoinPath(ref.Path)
+	u.RawQuery = ref.RawQuery
+
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// Tracer returns the OpenTelemetry tracer of the client, so callers can add their own spans.
+func (c *AtuinClient) Tracer() trace.Tracer {
+	return c.tracer
+}
+
+// Do sends the request once the rate limiter allows it. The limiter is shared by every caller
+// of the client, so concurrent resource operations are throttled together. Every request is traced
+// with its host, endpoint, status and resend count, but never with headers or bodies, as those carry
+// credentials. The resend count is the number of times the request was sent again, by following a
+// redirect or by a retry of the transport.
 func (c *AtuinClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, span := c.tracer.Start(req.Context(), req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	attempts := 0
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) { attempts++ },
+	})
+
+	req = req.WithContext(ctx)
 	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := c.send(req)
+
+	if attempts > 1 {
+		span.SetAttributes(attribute.Int("http.request.resend_count", attempts-1))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// send sends the request once the rate limiter allows it.
+func (c *AtuinClient) send(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
 	return c.client.Do(req)
 }
 
-func (c *AtuinClient) CreateUser(username, password, email string) (string, error) {
+func (c *AtuinClient) CreateUser(ctx context.Context, username, password, email string) (string, error) {
 	values := map[string]string{"username": username, "password": password, "email": email}
 
 	jsonValue, _ := json.Marshal(values)
 
-	request, err := http.NewRequest("POST", c.host+"/register", bytes.NewBuffer(jsonValue))
+	request, err := c.newRequest(ctx, "POST", "/register", bytes.NewBuffer(jsonValue))
 	if err != nil {
 		return "", err
 	}
@@ -76,8 +317,8 @@ func (c *AtuinClient) CreateUser(username, password, email string) (string, erro
 	return s.Session, nil
 }
 
-func (c *AtuinClient) UpdatePassword(username, password, newpassword string) error {
-	sessionToken, err := c.Login(username, password)
+func (c *AtuinClient) UpdatePassword(ctx context.Context, username, password, newpassword string) error {
+	sessionToken, err := c.Login(ctx, username, password)
 	if err != nil {
 		return err
 	}
@@ -86,7 +327,7 @@ func (c *AtuinClient) UpdatePassword(username, password, newpassword string) err
 
 	jsonValue, _ := json.Marshal(values)
 
-	request, err := http.NewRequest("PATCH", c.host+"/account/password", bytes.NewBuffer(jsonValue))
+	request, err := c.newRequest(ctx, "PATCH", "/account/password", bytes.NewBuffer(jsonValue))
 	if err != nil {
 		return err
 	}
@@ -106,13 +347,42 @@ func (c *AtuinClient) UpdatePassword(username, password, newpassword string) err
 	return nil
 }
 
-func (c *AtuinClient) DeleteUser(username, password string) error {
-	sessionToken, err := c.Login(username, password)
+// UpdateEmail changes the email of the user. Servers without an endpoint to change the email, which
+// includes every Atuin server release so far, make it return an error that wraps errors.ErrUnsupported.
+func (c *AtuinClient) UpdateEmail(ctx context.Context, sessionToken, email string) error {
+	request, err := c.newAuthorizedRequest(ctx, "PATCH", "/account/email", sessionToken, map[string]string{"email": email})
 	if err != nil {
 		return err
 	}
 
-	request, err := http.NewRequest("DELETE", c.host+"/account", nil)
+	resp, err := c.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return fmt.Errorf("the Atuin server cannot change the email of a user: %w", errors.ErrUnsupported)
+	default:
+		return responseError(resp)
+	}
+}
+
+func (c *AtuinClient) DeleteUser(ctx context.Context, username, password string) error {
+	sessionToken, err := c.Login(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteAccount(ctx, sessionToken)
+}
+
+// DeleteAccount deletes the user the session belongs to.
+func (c *AtuinClient) DeleteAccount(ctx context.Context, sessionToken string) error {
+	request, err := c.newRequest(ctx, "DELETE", "/account", nil)
 	if err != nil {
 		return err
 	}
@@ -123,20 +393,21 @@ func (c *AtuinClient) DeleteUser(username, password string) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error creating user: %s", resp.Status)
+		return fmt.Errorf("error deleting user: %s", resp.Status)
 	}
 
 	return nil
 }
 
-func (c *AtuinClient) Login(username, password string) (string, error) {
+func (c *AtuinClient) Login(ctx context.Context, username, password string) (string, error) {
 	values := map[string]string{"username": username, "password": password}
 
 	jsonValue, _ := json.Marshal(values)
 
-	request, err := http.NewRequest("POST", c.host+"/login", bytes.NewBuffer(jsonValue))
+	request, err := c.newRequest(ctx, "POST", "/login", bytes.NewBuffer(jsonValue))
 	if err != nil {
 		return "", err
 	}
@@ -149,13 +420,7 @@ func (c *AtuinClient) Login(username, password string) (string, error) {
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 
-		var e ErrorMessage
-		err = json.NewDecoder(resp.Body).Decode(&e)
-		if err != nil {
-			return "", fmt.Errorf("unexpected error: %v", resp.Body)
-		}
-
-		return "", fmt.Errorf("%s", e.Reason)
+		return "", responseError(resp)
 	}
 
 	defer resp.Body.Close()
@@ -167,6 +432,56 @@ func (c *AtuinClient) Login(username, password string) (string, error) {
 	return s.Session, nil
 }
 
+// Me returns the username of the user the session belongs to.
+func (c *AtuinClient) Me(ctx context.Context, sessionToken string) (string, error) {
+	request, err := c.newAuthorizedRequest(ctx, "GET", "/api/v0/me", sessionToken, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	var me struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&me); err != nil {
+		return "", err
+	}
+
+	return me.Username, nil
+}
+
+// UserExists looks up a user by username. It does not require a login.
+func (c *AtuinClient) UserExists(ctx context.Context, username string) (bool, error) {
+	request, err := c.newRequest(ctx, "GET", "/user/"+url.PathEscape(username), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, responseError(resp)
+	}
+}
+
 func GenerateEncryptionKey() (string, error) {
 	key := make([]byte, 32)
 
@@ -0,0 +1,109 @@
+package atuin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DeleteHistory deletes the history entries with the given ids. The records of the entries are removed
+// from the server by replacing the record store, like Purge, so their ciphertext does not remain
+// server-side. Delete records for the entries are appended to the history record chain of host, so
+// Atuin clients that already synced the entries remove them from their local history. The remaining
+// records keep their idx, so deleted records leave gaps in their record chains.
+func (c *AtuinClient) DeleteHistory(ctx context.Context, sessionToken, key, host string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	var keep []Record
+	removed := 0
+
+	for _, chain := range status.Chains() {
+		records, err := c.Records(ctx, sessionToken, chain.Host, chain.Tag)
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			if records[i].Tag == HistoryTag {
+				data, err := DecryptRecord(&records[i], decodedKey)
+				if err != nil {
+					return fmt.Errorf("record %s (host %s, idx %d): %w", records[i].ID, records[i].Host.ID, records[i].Idx, err)
+				}
+
+				h, err := UnmarshalHistoryRecord(data, records[i].Version)
+				if err != nil {
+					return fmt.Errorf("record %s (host %s, idx %d): %w", records[i].ID, records[i].Host.ID, records[i].Idx, err)
+				}
+
+				if h.Entry != nil && remove[h.Entry.ID] {
+					removed++
+					continue
+				}
+			}
+
+			keep = append(keep, records[i])
+		}
+	}
+
+	if removed == 0 {
+		return nil
+	}
+
+	history := make([]HistoryRecord, len(ids))
+	for i, id := range ids {
+		history[i] = HistoryRecord{DeletedID: id}
+	}
+
+	// The delete records follow the last record the clients may have synced, even if it was removed
+	deletes, err := newHistoryRecords(decodedKey, host, nextHistoryIdx(status, host), history)
+	if err != nil {
+		return err
+	}
+
+	return c.ReplaceStore(ctx, sessionToken, append(keep, deletes...))
+}
+
+// DeleteHistoryMatching decrypts the history of the user, deletes every entry that matches the filter,
+// and returns the ids of the deleted entries. To prevent deleting the complete history by accident,
+// the filter must not be empty.
+func (c *AtuinClient) DeleteHistoryMatching(ctx context.Context, sessionToken, key, host string, filter HistoryFilter) ([]string, error) {
+	if filter.IsEmpty() {
+		return nil, errors.New("refusing to delete history with an empty filter")
+	}
+
+	history, err := c.History(ctx, sessionToken, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range history {
+		if filter.Match(e) {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	err = c.DeleteHistory(ctx, sessionToken, key, host, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
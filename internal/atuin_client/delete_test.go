@@ -0,0 +1,102 @@
+package atuin
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteHistory(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	second := testHistoryEntry("a2", "pwd", time.Unix(200, 0).UTC())
+	store, client := newTestStore(t, testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first}, HistoryRecord{Entry: &second})...)
+
+	err := client.DeleteHistory(t.Context(), "token", key, "host-2", []string{"a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := client.History(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []HistoryEntry{second}, history)
+
+	// The record of a1 is removed from the server, and a delete record is appended for synced clients
+	assert.Equal(t, []HistoryRecord{{Entry: &second}, {DeletedID: "a1"}}, testStoreHistory(t, store, decodedKey))
+	assert.Equal(t, uint64(1), store.records[0].Idx)
+}
+
+func TestDeleteHistoryUnknown(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	store, client := newTestStore(t, testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first})...)
+	records := store.records
+
+	err := client.DeleteHistory(t.Context(), "token", key, "host-1", []string{"a2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, records, store.records)
+}
+
+// testStoreHistory decrypts the history records in the store, in the order they are stored.
+func testStoreHistory(t *testing.T, store *testStore, key []byte) []HistoryRecord {
+	t.Helper()
+
+	var history []HistoryRecord
+	for i := range store.records {
+		data, err := DecryptRecord(&store.records[i], key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h, err := UnmarshalHistoryRecord(data, store.records[i].Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history = append(history, h)
+	}
+	return history
+}
+
+func TestDeleteHistoryMatching(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	second := testHistoryEntry("a2", "export AWS_SECRET_ACCESS_KEY=hunter2", time.Unix(200, 0).UTC())
+	third := testHistoryEntry("a3", "export GITHUB_TOKEN=ghp_123", time.Unix(300, 0).UTC())
+	store, client := newTestStore(t, testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first}, HistoryRecord{Entry: &second}, HistoryRecord{Entry: &third})...)
+
+	deleted, err := client.DeleteHistoryMatching(t.Context(), "token", key, "host-1", HistoryFilter{
+		Command: regexp.MustCompile(`(SECRET_ACCESS_KEY|TOKEN)=`),
+		Until:   time.Unix(250, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"a2"}, deleted)
+	assert.Equal(t, []HistoryRecord{{Entry: &first}, {Entry: &third}, {DeletedID: "a2"}}, testStoreHistory(t, store, decodedKey))
+
+	history, err := client.History(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []HistoryEntry{first, third}, history)
+}
+
+func TestDeleteHistoryMatchingEmptyFilter(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	_, client := newTestStore(t)
+
+	_, err := client.DeleteHistoryMatching(t.Context(), "token", key, "host-1", HistoryFilter{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,366 @@
+package atuin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// Records in the Atuin record store are encrypted the same way the Atuin client does it: every record
+// gets a random content encryption key (CEK), which encrypts the record as a PASETO v4.local token. The
+// CEK itself is wrapped with the user's encryption key as a PASERK k4.local-wrap.pie, and stored next
+// to the token together with the id of the wrapping key.
+
+const (
+	pasetoHeader   = "v4.local."
+	pieHeader      = "k4.local-wrap.pie."
+	localKeyHeader = "k4.local."
+	keyIDHeader    = "k4.lid."
+)
+
+var ErrDecryption = errors.New("unable to decrypt record")
+
+// wrappedKey is the content encryption key as it is stored in a record.
+type wrappedKey struct {
+	Wpk string `json:"wpk"`
+	Kid string `json:"kid"`
+}
+
+// assertions are the implicit assertions that bind an encrypted record to its metadata. The field
+// order must match the Atuin client, as the JSON encoding is part of the authenticated data.
+type assertions struct {
+	ID      string `json:"id"`
+	Idx     uint64 `json:"idx"`
+	Version string `json:"version"`
+	Tag     string `json:"tag"`
+	Host    string `json:"host"`
+}
+
+type payload struct {
+	Data string `json:"data"`
+}
+
+func recordAssertions(r *Record) []byte {
+	a, _ := json.Marshal(assertions{ID: r.ID, Idx: r.Idx, Version: r.Version, Tag: r.Tag, Host: r.Host.ID})
+	return a
+}
+
+// DecodeEncryptionKey decodes a base64 encoded encryption key, as returned by GenerateEncryptionKey.
+func DecodeEncryptionKey(key string) ([]byte, error) {
+	decoded, err := b64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(decoded))
+	}
+
+	return decoded, nil
+}
+
+// DecodeKeyFile decodes the contents of the key file of the Atuin client, and returns the key base64
+// encoded. The client writes the key as a msgpack array of 32 integers, base64 encoded; older clients
+// wrote the raw 32 bytes instead.
+func DecodeKeyFile(contents string) (string, error) {
+	decoded, err := b64.StdEncoding.DecodeString(strings.TrimSpace(contents))
+	if err != nil {
+		return "", fmt.Errorf("key file is not base64 encoded: %w", err)
+	}
+
+	if len(decoded) == 32 {
+		return b64.StdEncoding.EncodeToString(decoded), nil
+	}
+
+	r := &msgpackReader{buf: decoded}
+
+	n, err := r.readArrayLen()
+	if err != nil {
+		return "", err
+	}
+	if n != 32 {
+		return "", fmt.Errorf("encryption key must be 32 bytes, got %d", n)
+	}
+
+	key := make([]byte, n)
+	for i := range key {
+		v, err := r.readUint()
+		if err != nil {
+			return "", err
+		}
+		if v > math.MaxUint8 {
+			return "", fmt.Errorf("encryption key byte %d out of range", v)
+		}
+		key[i] = byte(v)
+	}
+
+	return b64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseEncryptionKey parses an encryption key in any of the forms the Atuin client shows or stores it
+// in: a BIP39 mnemonic, the base64 encoded key, or the contents of its key file. It returns the key
+// base64 encoded.
+func ParseEncryptionKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+
+	if strings.Contains(key, " ") {
+		entropy, err := bip39.EntropyFromMnemonic(key)
+		if err != nil {
+			return "", fmt.Errorf("invalid mnemonic: %w", err)
+		}
+		if len(entropy) != 32 {
+			return "", fmt.Errorf("encryption key must be 32 bytes, the mnemonic has %d", len(entropy))
+		}
+		return b64.StdEncoding.EncodeToString(entropy), nil
+	}
+
+	return DecodeKeyFile(key)
+}
+
+// EncodeKeyFile encodes a base64 encoded key the way the Atuin client writes its key file.
+func EncodeKeyFile(key string) (string, error) {
+	decoded, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	w := &msgpackWriter{}
+	w.writeArrayLen(len(decoded))
+	for _, b := range decoded {
+		// Like rmp's write_uint, bytes below 128 are written as a positive fixint
+		if b < 0x80 {
+			w.buf = append(w.buf, b)
+		} else {
+			w.writeU8(b)
+		}
+	}
+
+	return b64.StdEncoding.EncodeToString(w.buf), nil
+}
+
+// EncryptRecord encrypts data with a fresh content encryption key, and stores the result in the record.
+func EncryptRecord(r *Record, data []byte, key []byte) error {
+	cek := make([]byte, 32)
+	if _, err := rand.Read(cek); err != nil {
+		return err
+	}
+
+	p, _ := json.Marshal(payload{Data: b64.RawURLEncoding.EncodeToString(data)})
+
+	token, err := pasetoEncrypt(cek, p, recordAssertions(r))
+	if err != nil {
+		return err
+	}
+
+	wrapped, err := wrapKey(cek, key)
+	if err != nil {
+		return err
+	}
+
+	r.Data = EncryptedData{Data: token, ContentEncryptionKey: wrapped}
+	return nil
+}
+
+// DecryptRecord decrypts the data of a record with the given encryption key.
+func DecryptRecord(r *Record, key []byte) ([]byte, error) {
+	cek, err := unwrapKey(r.Data.ContentEncryptionKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := pasetoDecrypt(cek, r.Data.Data, recordAssertions(r))
+	if err != nil {
+		return nil, err
+	}
+
+	var p payload
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		return nil, fmt.Errorf("%w: invalid payload: %s", ErrDecryption, err)
+	}
+
+	data, err := b64.RawURLEncoding.DecodeString(p.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid payload data: %s", ErrDecryption, err)
+	}
+
+	return data, nil
+}
+
+// ReencryptRecord re-wraps the content encryption key of a record with a new encryption key. The
+// record data itself is left untouched.
+func ReencryptRecord(r *Record, oldKey, newKey []byte) error {
+	cek, err := unwrapKey(r.Data.ContentEncryptionKey, oldKey)
+	if err != nil {
+		return err
+	}
+
+	wrapped, err := wrapKey(cek, newKey)
+	if err != nil {
+		return err
+	}
+
+	r.Data.ContentEncryptionKey = wrapped
+	return nil
+}
+
+func wrapKey(cek, key []byte) (string, error) {
+	wpk, err := pieWrap(cek, key)
+	if err != nil {
+		return "", err
+	}
+
+	w, _ := json.Marshal(wrappedKey{Wpk: wpk, Kid: keyID(key)})
+	return string(w), nil
+}
+
+func unwrapKey(wrapped string, key []byte) ([]byte, error) {
+	var w wrappedKey
+	if err := json.Unmarshal([]byte(wrapped), &w); err != nil {
+		return nil, fmt.Errorf("%w: invalid content encryption key: %s", ErrDecryption, err)
+	}
+
+	if kid := keyID(key); w.Kid != kid {
+		return nil, fmt.Errorf("%w: record was encrypted with key %s, not %s", ErrDecryption, w.Kid, kid)
+	}
+
+	return pieUnwrap(w.Wpk, key)
+}
+
+// keyID returns the PASERK k4.lid identifier of a local key.
+func keyID(key []byte) string {
+	h, _ := blake2b.New(33, nil)
+	h.Write([]byte(keyIDHeader))
+	h.Write([]byte(localKeyHeader + b64.RawURLEncoding.EncodeToString(key)))
+	return keyIDHeader + b64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func keyedHash(size int, key []byte, parts ...[]byte) []byte {
+	h, _ := blake2b.New(size, key)
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func xchacha20(key, nonce, data []byte) ([]byte, error) {
+	c, err := chacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out, nil
+}
+
+// pae implements the PASETO pre-authentication encoding.
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(len(pieces)))
+	for _, p := range pieces {
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(p)))
+		buf.Write(p)
+	}
+	return buf.Bytes()
+}
+
+func pasetoEncrypt(key, message, implicit []byte) (string, error) {
+	n := make([]byte, 32)
+	if _, err := rand.Read(n); err != nil {
+		return "", err
+	}
+
+	tmp := keyedHash(56, key, []byte("paseto-encryption-key"), n)
+	ak := keyedHash(32, key, []byte("paseto-auth-key-for-aead"), n)
+
+	c, err := xchacha20(tmp[:32], tmp[32:], message)
+	if err != nil {
+		return "", err
+	}
+
+	t := keyedHash(32, ak, pae([]byte(pasetoHeader), n, c, nil, implicit))
+
+	return pasetoHeader + b64.RawURLEncoding.EncodeToString(bytes.Join([][]byte{n, c, t}, nil)), nil
+}
+
+func pasetoDecrypt(key []byte, token string, implicit []byte) ([]byte, error) {
+	if !strings.HasPrefix(token, pasetoHeader) {
+		return nil, fmt.Errorf("%w: token is not a v4.local PASETO", ErrDecryption)
+	}
+
+	body, footer, _ := strings.Cut(strings.TrimPrefix(token, pasetoHeader), ".")
+
+	f, err := b64.RawURLEncoding.DecodeString(footer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid token footer", ErrDecryption)
+	}
+
+	b, err := b64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(b) < 64 {
+		return nil, fmt.Errorf("%w: invalid token body", ErrDecryption)
+	}
+
+	n, c, t := b[:32], b[32:len(b)-32], b[len(b)-32:]
+
+	tmp := keyedHash(56, key, []byte("paseto-encryption-key"), n)
+	ak := keyedHash(32, key, []byte("paseto-auth-key-for-aead"), n)
+
+	expected := keyedHash(32, ak, pae([]byte(pasetoHeader), n, c, f, implicit))
+	if subtle.ConstantTimeCompare(t, expected) != 1 {
+		return nil, fmt.Errorf("%w: invalid token authentication tag", ErrDecryption)
+	}
+
+	return xchacha20(tmp[:32], tmp[32:], c)
+}
+
+func pieWrap(ptk, key []byte) (string, error) {
+	n := make([]byte, 32)
+	if _, err := rand.Read(n); err != nil {
+		return "", err
+	}
+
+	x := keyedHash(56, key, []byte{0x80}, n)
+	ak := keyedHash(32, key, []byte{0x81}, n)
+
+	c, err := xchacha20(x[:32], x[32:], ptk)
+	if err != nil {
+		return "", err
+	}
+
+	t := keyedHash(32, ak, []byte(pieHeader), n, c)
+
+	return pieHeader + b64.RawURLEncoding.EncodeToString(bytes.Join([][]byte{t, n, c}, nil)), nil
+}
+
+func pieUnwrap(wrapped string, key []byte) ([]byte, error) {
+	if !strings.HasPrefix(wrapped, pieHeader) {
+		return nil, fmt.Errorf("%w: key is not a k4.local-wrap.pie PASERK", ErrDecryption)
+	}
+
+	b, err := b64.RawURLEncoding.DecodeString(strings.TrimPrefix(wrapped, pieHeader))
+	if err != nil || len(b) != 96 {
+		return nil, fmt.Errorf("%w: invalid wrapped key", ErrDecryption)
+	}
+
+	t, n, c := b[:32], b[32:64], b[64:]
+
+	ak := keyedHash(32, key, []byte{0x81}, n)
+	expected := keyedHash(32, ak, []byte(pieHeader), n, c)
+	if subtle.ConstantTimeCompare(t, expected) != 1 {
+		return nil, fmt.Errorf("%w: invalid wrapped key authentication tag", ErrDecryption)
+	}
+
+	x := keyedHash(56, key, []byte{0x80}, n)
+	return xchacha20(x[:32], x[32:], c)
+}
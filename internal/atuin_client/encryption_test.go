@@ -0,0 +1,172 @@
+package atuin
+
+import (
+	b64 "encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeEncryptionKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return decoded
+}
+
+func testRecord(idx uint64) Record {
+	return Record{
+		ID:        "0190b6e2-5b8a-7c3e-9a1d-0f0e5c6f8a1b",
+		Idx:       idx,
+		Host:      Host{ID: "0190b6e2-0000-7000-8000-000000000001"},
+		Timestamp: 1720000000000000000,
+		Version:   "v0",
+		Tag:       "history",
+	}
+}
+
+func TestEncryptDecryptRecord(t *testing.T) {
+	key := testKey(t)
+	record := testRecord(0)
+
+	err := EncryptRecord(&record, []byte("the luggage"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := DecryptRecord(&record, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []byte("the luggage"), decrypted)
+}
+
+func TestDecryptRecordWithWrongKey(t *testing.T) {
+	record := testRecord(0)
+
+	err := EncryptRecord(&record, []byte("the luggage"), testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = DecryptRecord(&record, testKey(t))
+	assert.True(t, errors.Is(err, ErrDecryption))
+}
+
+func TestDecryptRecordWithChangedMetadata(t *testing.T) {
+	key := testKey(t)
+	record := testRecord(0)
+
+	err := EncryptRecord(&record, []byte("the luggage"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record.Idx = 1
+
+	_, err = DecryptRecord(&record, key)
+	assert.True(t, errors.Is(err, ErrDecryption))
+}
+
+func TestReencryptRecord(t *testing.T) {
+	oldKey, newKey := testKey(t), testKey(t)
+	record := testRecord(0)
+
+	err := EncryptRecord(&record, []byte("the luggage"), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ReencryptRecord(&record, oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := DecryptRecord(&record, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte("the luggage"), decrypted)
+
+	_, err = DecryptRecord(&record, oldKey)
+	assert.True(t, errors.Is(err, ErrDecryption))
+}
+
+func TestKeyFile(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i * 8)
+	}
+	b64Key := b64.StdEncoding.EncodeToString(key)
+
+	// A msgpack array16 of 32 unsigned integers, as written by rmp
+	msgpack := []byte{0xdc, 0x00, 0x20}
+	for _, b := range key {
+		if b < 0x80 {
+			msgpack = append(msgpack, b)
+		} else {
+			msgpack = append(msgpack, 0xcc, b)
+		}
+	}
+
+	encoded, err := EncodeKeyFile(b64Key)
+	assert.NoError(t, err)
+	assert.Equal(t, b64.StdEncoding.EncodeToString(msgpack), encoded)
+
+	decoded, err := DecodeKeyFile(encoded + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, b64Key, decoded)
+
+	// Older clients wrote the raw key
+	decoded, err = DecodeKeyFile(b64Key)
+	assert.NoError(t, err)
+	assert.Equal(t, b64Key, decoded)
+
+	_, err = DecodeKeyFile(b64.StdEncoding.EncodeToString([]byte{0x93, 0x01, 0x02, 0x03}))
+	assert.Error(t, err)
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mnemonic, err := ConvertEncryptionKeyToBip39(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile, err := EncodeKeyFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, form := range []string{key, mnemonic, keyFile, keyFile + "\n"} {
+		parsed, err := ParseEncryptionKey(form)
+		assert.NoError(t, err)
+		assert.Equal(t, key, parsed)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"not a key",
+		b64.StdEncoding.EncodeToString([]byte("too short")),
+		// A valid mnemonic of a 16 byte key
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+	} {
+		_, err := ParseEncryptionKey(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,188 @@
+package atuin
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryFilter selects history entries. Zero values match every entry.
+type HistoryFilter struct {
+	// Since and Until bound the timestamp of the entries, Since inclusive and Until exclusive.
+	Since time.Time
+	Until time.Time
+	// Hostname matches either the full Atuin hostname ("host:user"), or only the host part of it.
+	Hostname string
+	// CwdPrefix matches entries whose working directory starts with the prefix.
+	CwdPrefix string
+	// Command matches entries whose command matches the regular expression.
+	Command *regexp.Regexp
+}
+
+// IsEmpty reports whether the filter matches every entry.
+func (f HistoryFilter) IsEmpty() bool {
+	return f.Since.IsZero() && f.Until.IsZero() && f.Hostname == "" && f.CwdPrefix == "" && f.Command == nil
+}
+
+func (f HistoryFilter) Match(e HistoryEntry) bool {
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !e.Timestamp.Before(f.Until) {
+		return false
+	}
+
+	if f.Hostname != "" {
+		host, _, _ := strings.Cut(e.Hostname, ":")
+		if e.Hostname != f.Hostname && host != f.Hostname {
+			return false
+		}
+	}
+
+	if f.CwdPrefix != "" && !strings.HasPrefix(e.Cwd, f.CwdPrefix) {
+		return false
+	}
+
+	if f.Command != nil && !f.Command.MatchString(e.Command) {
+		return false
+	}
+
+	return true
+}
+
+// HistoryWriter writes history entries in an export format.
+type HistoryWriter interface {
+	Write(e HistoryEntry) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatJSONLines ExportFormat = "jsonl"
+	ExportFormatCSV       ExportFormat = "csv"
+)
+
+// NewHistoryWriter returns a HistoryWriter for the given export format.
+func NewHistoryWriter(format ExportFormat, w io.Writer) (HistoryWriter, error) {
+	switch format {
+	case ExportFormatJSONLines:
+		return NewJSONLinesHistoryWriter(w), nil
+	case ExportFormatCSV:
+		return NewCSVHistoryWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+type jsonLinesHistoryWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLinesHistoryWriter returns a HistoryWriter that writes every entry as a JSON object on its own line.
+func NewJSONLinesHistoryWriter(w io.Writer) HistoryWriter {
+	return &jsonLinesHistoryWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *jsonLinesHistoryWriter) Write(e HistoryEntry) error {
+	return w.encoder.Encode(e)
+}
+
+func (w *jsonLinesHistoryWriter) Flush() error {
+	return nil
+}
+
+var csvHeader = []string{"id", "timestamp", "duration", "exit", "command", "cwd", "session", "hostname"}
+
+type csvHistoryWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// NewCSVHistoryWriter returns a HistoryWriter that writes entries as CSV, preceded by a header row.
+func NewCSVHistoryWriter(w io.Writer) HistoryWriter {
+	return &csvHistoryWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvHistoryWriter) Write(e HistoryEntry) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	return w.writer.Write([]string{
+		e.ID,
+		e.Timestamp.Format(time.RFC3339Nano),
+		strconv.FormatInt(e.Duration, 10),
+		strconv.FormatInt(e.Exit, 10),
+		e.Command,
+		e.Cwd,
+		e.Session,
+		e.Hostname,
+	})
+}
+
+func (w *csvHistoryWriter) Flush() error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// ExportHistory writes the decrypted history entries of the user that match the filter to w, per host in
+// the order they were recorded, and returns the number of exported entries. Every entry is written as soon
+// as its page of records is decrypted, so the history never has to fit in memory. As a later record may
+// delete an entry, the records are downloaded twice: once to collect the deleted entries, and once to export.
+func (c *AtuinClient) ExportHistory(ctx context.Context, sessionToken, key string, filter HistoryFilter, w HistoryWriter) (int, error) {
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := map[string]bool{}
+	err = c.eachHistoryRecord(ctx, sessionToken, decodedKey, func(h HistoryRecord) error {
+		switch {
+		case h.Entry == nil:
+			deleted[h.DeletedID] = true
+		case h.Entry.DeletedAt != nil:
+			deleted[h.Entry.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	err = c.eachHistoryRecord(ctx, sessionToken, decodedKey, func(h HistoryRecord) error {
+		if h.Entry == nil || deleted[h.Entry.ID] || !filter.Match(*h.Entry) {
+			return nil
+		}
+
+		if err := w.Write(*h.Entry); err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	return exported, w.Flush()
+}
@@ -0,0 +1,92 @@
+package atuin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	entry := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+
+	assert.True(t, HistoryFilter{}.Match(entry))
+	assert.True(t, HistoryFilter{Since: time.Unix(100, 0), Until: time.Unix(101, 0)}.Match(entry))
+	assert.False(t, HistoryFilter{Since: time.Unix(101, 0)}.Match(entry))
+	assert.False(t, HistoryFilter{Until: time.Unix(100, 0)}.Match(entry))
+	assert.True(t, HistoryFilter{Hostname: "octavo"}.Match(entry))
+	assert.True(t, HistoryFilter{Hostname: "octavo:rincewind"}.Match(entry))
+	assert.False(t, HistoryFilter{Hostname: "octavo:twoflower"}.Match(entry))
+	assert.True(t, HistoryFilter{CwdPrefix: "/home/rincewind"}.Match(entry))
+	assert.False(t, HistoryFilter{CwdPrefix: "/home/twoflower"}.Match(entry))
+}
+
+func TestJSONLinesHistoryWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLinesHistoryWriter(&buf)
+
+	assert.NoError(t, w.Write(testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())))
+	assert.NoError(t, w.Write(testHistoryEntry("a2", "pwd", time.Unix(200, 0).UTC())))
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, `{"id":"a1","timestamp":"1970-01-01T00:01:40Z","duration":1500000,"exit":-1,"command":"ls","cwd":"/home/rincewind/unseen-university","session":"0190b6e25b8a7c3e9a1d0f0e5c6f8a1b","hostname":"octavo:rincewind"}
+{"id":"a2","timestamp":"1970-01-01T00:03:20Z","duration":1500000,"exit":-1,"command":"pwd","cwd":"/home/rincewind/unseen-university","session":"0190b6e25b8a7c3e9a1d0f0e5c6f8a1b","hostname":"octavo:rincewind"}
+`, buf.String())
+}
+
+func TestCSVHistoryWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVHistoryWriter(&buf)
+
+	assert.NoError(t, w.Write(testHistoryEntry("a1", `echo "hello, world"`, time.Unix(100, 0).UTC())))
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, `id,timestamp,duration,exit,command,cwd,session,hostname
+a1,1970-01-01T00:01:40Z,1500000,-1,"echo ""hello, world""",/home/rincewind/unseen-university,0190b6e25b8a7c3e9a1d0f0e5c6f8a1b,octavo:rincewind
+`, buf.String())
+}
+
+func TestExportHistory(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	second := testHistoryEntry("a2", "pwd", time.Unix(200, 0).UTC())
+	_, client := newTestStore(t, testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first}, HistoryRecord{Entry: &second})...)
+
+	var buf bytes.Buffer
+	exported, err := client.ExportHistory(t.Context(), "token", key, HistoryFilter{Since: time.Unix(150, 0)}, NewCSVHistoryWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, exported)
+	assert.Contains(t, buf.String(), "a2,")
+	assert.NotContains(t, buf.String(), "a1,")
+}
+
+func TestExportHistoryDeleted(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	second := testHistoryEntry("a2", "pwd", time.Unix(200, 0).UTC())
+	third := testHistoryEntry("a3", "whoami", time.Unix(300, 0).UTC())
+	// The tombstone for a1 is recorded on another host, after a1 was downloaded
+	_, client := newTestStore(t, append(
+		testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first}, HistoryRecord{Entry: &second}),
+		testHistoryChain(t, decodedKey, "host-2", HistoryRecord{Entry: &third}, HistoryRecord{DeletedID: "a1"})...,
+	)...)
+
+	var buf bytes.Buffer
+	exported, err := client.ExportHistory(t.Context(), "token", key, HistoryFilter{}, NewJSONLinesHistoryWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, exported)
+	assert.NotContains(t, buf.String(), `"id":"a1"`)
+	assert.Contains(t, buf.String(), `"id":"a2"`)
+	assert.Contains(t, buf.String(), `"id":"a3"`)
+}
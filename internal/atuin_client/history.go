@@ -0,0 +1,332 @@
+package atuin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HistoryTag     = "history"
+	HistoryVersion = "v0"
+)
+
+const (
+	historyRecordCreate = 0
+	historyRecordDelete = 1
+)
+
+// HistoryEntry is a single command in the shell history of an Atuin user.
+type HistoryEntry struct {
+	ID        string     `json:"id"`
+	Timestamp time.Time  `json:"timestamp"`
+	Duration  int64      `json:"duration"`
+	Exit      int64      `json:"exit"`
+	Command   string     `json:"command"`
+	Cwd       string     `json:"cwd"`
+	Session   string     `json:"session"`
+	Hostname  string     `json:"hostname"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// HistoryRecord is the decrypted data of a record with the history tag: either the creation of a
+// history entry, or the deletion of the entry with DeletedID.
+type HistoryRecord struct {
+	Entry     *HistoryEntry
+	DeletedID string
+}
+
+// MarshalHistoryRecord encodes a history record the same way the Atuin client does.
+func MarshalHistoryRecord(h HistoryRecord) []byte {
+	w := &msgpackWriter{}
+
+	if h.Entry == nil {
+		w.writeU8(historyRecordDelete)
+		w.writeStr(h.DeletedID)
+		return w.buf
+	}
+
+	w.writeU8(historyRecordCreate)
+	w.writeBin(marshalHistoryEntry(h.Entry))
+	return w.buf
+}
+
+func marshalHistoryEntry(e *HistoryEntry) []byte {
+	w := &msgpackWriter{}
+
+	w.writeU16(0)
+	w.writeArrayLen(9)
+	w.writeStr(e.ID)
+	w.writeU64(uint64(e.Timestamp.UnixNano()))
+	w.writeSint(e.Duration)
+	w.writeSint(e.Exit)
+	w.writeStr(e.Command)
+	w.writeStr(e.Cwd)
+	w.writeStr(e.Session)
+	w.writeStr(e.Hostname)
+	if e.DeletedAt == nil {
+		w.writeNil()
+	} else {
+		w.writeU64(uint64(e.DeletedAt.UnixNano()))
+	}
+
+	return w.buf
+}
+
+// UnmarshalHistoryRecord decodes the decrypted data of a history record.
+func UnmarshalHistoryRecord(data []byte, version string) (HistoryRecord, error) {
+	if version != HistoryVersion {
+		return HistoryRecord{}, fmt.Errorf("unknown history record version %q", version)
+	}
+
+	r := &msgpackReader{buf: data}
+
+	recordType, err := r.readUint()
+	if err != nil {
+		return HistoryRecord{}, err
+	}
+
+	switch recordType {
+	case historyRecordCreate:
+		if _, err := r.readBinLen(); err != nil {
+			return HistoryRecord{}, err
+		}
+
+		entry, err := unmarshalHistoryEntry(r)
+		if err != nil {
+			return HistoryRecord{}, err
+		}
+
+		return HistoryRecord{Entry: entry}, nil
+	case historyRecordDelete:
+		id, err := r.readStr()
+		if err != nil {
+			return HistoryRecord{}, err
+		}
+
+		return HistoryRecord{DeletedID: id}, nil
+	default:
+		return HistoryRecord{}, fmt.Errorf("unknown history record type %d", recordType)
+	}
+}
+
+func unmarshalHistoryEntry(r *msgpackReader) (*HistoryEntry, error) {
+	version, err := r.readUint()
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 {
+		return nil, fmt.Errorf("expected history entry v0, found v%d", version)
+	}
+
+	fields, err := r.readArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	if fields != 9 {
+		return nil, fmt.Errorf("expected 9 history entry fields, found %d", fields)
+	}
+
+	e := &HistoryEntry{}
+
+	if e.ID, err = r.readStr(); err != nil {
+		return nil, err
+	}
+
+	timestamp, err := r.readUint()
+	if err != nil {
+		return nil, err
+	}
+	e.Timestamp = time.Unix(0, int64(timestamp)).UTC()
+
+	if e.Duration, err = r.readInt(); err != nil {
+		return nil, err
+	}
+	if e.Exit, err = r.readInt(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range []*string{&e.Command, &e.Cwd, &e.Session, &e.Hostname} {
+		if *s, err = r.readStr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !r.readNil() {
+		deletedAt, err := r.readUint()
+		if err != nil {
+			return nil, err
+		}
+		t := time.Unix(0, int64(deletedAt)).UTC()
+		e.DeletedAt = &t
+	}
+
+	return e, nil
+}
+
+// eachHistoryRecord downloads and decrypts the history records of the user page by page, and calls fn
+// with every record, per host in the order they were recorded.
+func (c *AtuinClient) eachHistoryRecord(ctx context.Context, sessionToken string, key []byte, fn func(h HistoryRecord) error) error {
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range status.Chains() {
+		if chain.Tag != HistoryTag {
+			continue
+		}
+
+		err := c.recordPages(ctx, sessionToken, chain.Host, chain.Tag, func(page []Record) error {
+			for i := range page {
+				data, err := DecryptRecord(&page[i], key)
+				if err != nil {
+					return fmt.Errorf("record %s (host %s, idx %d): %w", page[i].ID, page[i].Host.ID, page[i].Idx, err)
+				}
+
+				h, err := UnmarshalHistoryRecord(data, page[i].Version)
+				if err != nil {
+					return fmt.Errorf("record %s (host %s, idx %d): %w", page[i].ID, page[i].Host.ID, page[i].Idx, err)
+				}
+
+				if err := fn(h); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// History downloads and decrypts the history records of the user, and returns the history entries
+// that were not deleted, sorted by timestamp.
+func (c *AtuinClient) History(ctx context.Context, sessionToken, key string) ([]HistoryEntry, error) {
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]HistoryEntry{}
+	deleted := map[string]bool{}
+
+	err = c.eachHistoryRecord(ctx, sessionToken, decodedKey, func(h HistoryRecord) error {
+		if h.Entry == nil {
+			deleted[h.DeletedID] = true
+		} else if h.Entry.DeletedAt == nil {
+			entries[h.Entry.ID] = *h.Entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]HistoryEntry, 0, len(entries))
+	for id, entry := range entries {
+		if !deleted[id] {
+			history = append(history, entry)
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].Timestamp.Equal(history[j].Timestamp) {
+			return history[i].Timestamp.Before(history[j].Timestamp)
+		}
+		return history[i].ID < history[j].ID
+	})
+
+	return history, nil
+}
+
+// NewHostID returns a new id for a record chain host.
+func NewHostID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// PushHistory encrypts the history entries with the base64 encoded key, and appends them to the history
+// record chain of host. Use a host id that no Atuin client syncs from, as clients only append to
+// their own chain.
+func (c *AtuinClient) PushHistory(ctx context.Context, sessionToken, key, host string, entries []HistoryEntry) error {
+	history := make([]HistoryRecord, len(entries))
+	for i := range entries {
+		history[i] = HistoryRecord{Entry: &entries[i]}
+	}
+
+	return c.pushHistoryRecords(ctx, sessionToken, key, host, history)
+}
+
+func (c *AtuinClient) pushHistoryRecords(ctx context.Context, sessionToken, key, host string, history []HistoryRecord) error {
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return err
+	}
+
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	records, err := newHistoryRecords(decodedKey, host, nextHistoryIdx(status, host), history)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(records); start += recordPageSize {
+		end := min(start+recordPageSize, len(records))
+
+		if err := c.PushRecords(ctx, sessionToken, records[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextHistoryIdx returns the idx of the next record in the history record chain of host.
+func nextHistoryIdx(status *RecordStatus, host string) uint64 {
+	if last, ok := status.Hosts[host][HistoryTag]; ok {
+		return last + 1
+	}
+	return 0
+}
+
+// newHistoryRecords encrypts the history records for the history record chain of host, starting at idx.
+func newHistoryRecords(key []byte, host string, idx uint64, history []HistoryRecord) ([]Record, error) {
+	records := make([]Record, 0, len(history))
+	for _, h := range history {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+
+		r := Record{
+			ID:        id.String(),
+			Idx:       idx,
+			Host:      Host{ID: host},
+			Timestamp: uint64(time.Now().UnixNano()),
+			Version:   HistoryVersion,
+			Tag:       HistoryTag,
+		}
+
+		if err := EncryptRecord(&r, MarshalHistoryRecord(h), key); err != nil {
+			return nil, err
+		}
+
+		records = append(records, r)
+		idx++
+	}
+
+	return records, nil
+}
@@ -0,0 +1,88 @@
+package atuin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testHistoryEntry(id, command string, timestamp time.Time) HistoryEntry {
+	return HistoryEntry{
+		ID:        id,
+		Timestamp: timestamp,
+		Duration:  1500000,
+		Exit:      -1,
+		Command:   command,
+		Cwd:       "/home/rincewind/unseen-university",
+		Session:   "0190b6e25b8a7c3e9a1d0f0e5c6f8a1b",
+		Hostname:  "octavo:rincewind",
+	}
+}
+
+// testHistoryChain encrypts the history records as a record chain of a single host.
+func testHistoryChain(t *testing.T, key []byte, host string, history ...HistoryRecord) []Record {
+	t.Helper()
+
+	var records []Record
+	for idx, h := range history {
+		r := Record{
+			ID:        fmt.Sprintf("0190b6e2-5b8a-7c3e-9a1d-%012d", idx),
+			Idx:       uint64(idx),
+			Host:      Host{ID: host},
+			Timestamp: uint64(time.Now().UnixNano()),
+			Version:   HistoryVersion,
+			Tag:       HistoryTag,
+		}
+		if err := EncryptRecord(&r, MarshalHistoryRecord(h), key); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestHistoryRecordRoundTrip(t *testing.T) {
+	entry := testHistoryEntry("a1", "ls -la", time.Date(2024, 3, 13, 14, 27, 39, 105, time.UTC))
+
+	h, err := UnmarshalHistoryRecord(MarshalHistoryRecord(HistoryRecord{Entry: &entry}), HistoryVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &entry, h.Entry)
+
+	h, err = UnmarshalHistoryRecord(MarshalHistoryRecord(HistoryRecord{DeletedID: "a1"}), HistoryVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, h.Entry)
+	assert.Equal(t, "a1", h.DeletedID)
+}
+
+func TestMarshalHistoryDeleteRecord(t *testing.T) {
+	// rmp encoding of a delete record: write_u8(1), write_str("a1")
+	assert.Equal(t, []byte{0xcc, 0x01, 0xa2, 'a', '1'}, MarshalHistoryRecord(HistoryRecord{DeletedID: "a1"}))
+}
+
+func TestHistory(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	second := testHistoryEntry("a2", "export AWS_SECRET_ACCESS_KEY=hunter2", time.Unix(200, 0).UTC())
+	third := testHistoryEntry("a3", "make", time.Unix(50, 0).UTC())
+
+	records := append(
+		testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first}, HistoryRecord{Entry: &second}),
+		testHistoryChain(t, decodedKey, "host-2", HistoryRecord{Entry: &third}, HistoryRecord{DeletedID: "a2"})...,
+	)
+	_, client := newTestStore(t, records...)
+
+	history, err := client.History(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []HistoryEntry{third, first}, history)
+}
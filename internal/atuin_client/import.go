@@ -0,0 +1,242 @@
+package atuin
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportOptions sets the fields of imported history entries that shell history files do not record.
+type ImportOptions struct {
+	// Hostname of the imported entries, in the Atuin "host:user" format.
+	Hostname string
+	// Now is the time used to derive timestamps for entries without one. Defaults to the current time.
+	Now time.Time
+}
+
+// importedCommand is a command parsed from a history file, before it is turned into a HistoryEntry.
+type importedCommand struct {
+	command   string
+	timestamp *time.Time
+	duration  int64
+}
+
+// Like the Atuin importers, imported entries get an unknown exit code, duration and working directory,
+// and all entries of a single import share a new session.
+const (
+	importUnknownExit     = -1
+	importUnknownDuration = -1
+	importUnknownCwd      = "unknown"
+)
+
+// newHistoryEntries turns parsed commands into history entries. Commands without a timestamp are placed
+// a millisecond after the preceding command, or before Now if no command before them has a timestamp.
+func newHistoryEntries(commands []importedCommand, opts ImportOptions) ([]HistoryEntry, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	session, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	last := now.Add(-time.Duration(len(commands)) * time.Millisecond)
+	entries := make([]HistoryEntry, 0, len(commands))
+
+	for _, c := range commands {
+		if c.timestamp != nil {
+			last = *c.timestamp
+		} else {
+			last = last.Add(time.Millisecond)
+		}
+
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, HistoryEntry{
+			ID:        simpleUUID(id),
+			Timestamp: last.UTC(),
+			Duration:  c.duration,
+			Exit:      importUnknownExit,
+			Command:   c.command,
+			Cwd:       importUnknownCwd,
+			Session:   simpleUUID(session),
+			Hostname:  opts.Hostname,
+		})
+	}
+
+	return entries, nil
+}
+
+// simpleUUID formats a UUID without hyphens, as Atuin does for history and session ids.
+func simpleUUID(id uuid.UUID) string {
+	return strings.ReplaceAll(id.String(), "-", "")
+}
+
+var bashTimestamp = regexp.MustCompile(`^#(\d+)$`)
+
+// ParseBashHistory parses a bash history file. Timestamps are read from the comment lines bash writes
+// when HISTTIMEFORMAT is set.
+func ParseBashHistory(r io.Reader, opts ImportOptions) ([]HistoryEntry, error) {
+	var commands []importedCommand
+	var timestamp *time.Time
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := bashTimestamp.FindStringSubmatch(line); m != nil {
+			seconds, err := strconv.ParseInt(m[1], 10, 64)
+			if err == nil {
+				t := time.Unix(seconds, 0)
+				timestamp = &t
+				continue
+			}
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		commands = append(commands, importedCommand{command: line, timestamp: timestamp, duration: importUnknownDuration})
+		timestamp = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newHistoryEntries(commands, opts)
+}
+
+var zshExtended = regexp.MustCompile(`(?s)^: *(\d+):(\d+);(.*)$`)
+
+// ParseZshHistory parses a zsh history file, in either the extended or the plain format. Multi-line
+// commands are joined.
+func ParseZshHistory(r io.Reader, opts ImportOptions) ([]HistoryEntry, error) {
+	var commands []importedCommand
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := unmetafy(scanner.Text())
+
+		// A trailing backslash continues the command on the next line
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			line = strings.TrimSuffix(line, "\\") + "\n" + unmetafy(scanner.Text())
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		c := importedCommand{command: line, duration: importUnknownDuration}
+
+		if m := zshExtended.FindStringSubmatch(line); m != nil {
+			seconds, _ := strconv.ParseInt(m[1], 10, 64)
+			elapsed, _ := strconv.ParseInt(m[2], 10, 64)
+
+			t := time.Unix(seconds, 0)
+			c = importedCommand{command: m[3], timestamp: &t, duration: int64(time.Duration(elapsed) * time.Second)}
+		}
+
+		commands = append(commands, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newHistoryEntries(commands, opts)
+}
+
+// unmetafy decodes the metafied bytes zsh writes for non-ASCII characters: 0x83 followed by the
+// original byte XOR 0x20.
+func unmetafy(s string) string {
+	if !strings.Contains(s, "\x83") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x83 && i+1 < len(s) {
+			i++
+			b.WriteByte(s[i] ^ 0x20)
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ParseFishHistory parses a fish history file, which is a YAML-like list of entries with a cmd and a
+// when field.
+func ParseFishHistory(r io.Reader, opts ImportOptions) ([]HistoryEntry, error) {
+	var commands []importedCommand
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if cmd, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			commands = append(commands, importedCommand{command: unescapeFish(cmd), duration: importUnknownDuration})
+			continue
+		}
+
+		if when, ok := strings.CutPrefix(line, "  when: "); ok && len(commands) > 0 {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(when), 10, 64)
+			if err == nil {
+				t := time.Unix(seconds, 0)
+				commands[len(commands)-1].timestamp = &t
+			}
+		}
+
+		// Other fields, like the paths a command referred to, are not imported
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newHistoryEntries(commands, opts)
+}
+
+// unescapeFish decodes the escaping fish applies to commands in its history file.
+func unescapeFish(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func newHistoryScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// Allow for very long commands, like pasted scripts
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
@@ -0,0 +1,108 @@
+package atuin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testImportOptions = ImportOptions{Hostname: "octavo:rincewind", Now: time.Unix(1000, 0)}
+
+func commands(entries []HistoryEntry) []string {
+	var c []string
+	for _, e := range entries {
+		c = append(c, e.Command)
+	}
+	return c
+}
+
+func TestParseBashHistory(t *testing.T) {
+	entries, err := ParseBashHistory(strings.NewReader("ls\ncd /tmp\n\npwd\n"), testImportOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"ls", "cd /tmp", "pwd"}, commands(entries))
+	assert.Equal(t, time.Unix(1000, 0).Add(-2*time.Millisecond).UTC(), entries[0].Timestamp)
+	assert.Equal(t, time.Unix(1000, 0).UTC(), entries[2].Timestamp)
+	assert.Equal(t, "octavo:rincewind", entries[0].Hostname)
+	assert.Equal(t, int64(-1), entries[0].Exit)
+	assert.Equal(t, entries[0].Session, entries[2].Session)
+	assert.NotEqual(t, entries[0].ID, entries[1].ID)
+}
+
+func TestParseBashHistoryWithTimestamps(t *testing.T) {
+	entries, err := ParseBashHistory(strings.NewReader("#1710340059\nls\n#1710340100\ncd /tmp\npwd\n"), testImportOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"ls", "cd /tmp", "pwd"}, commands(entries))
+	assert.Equal(t, time.Unix(1710340059, 0).UTC(), entries[0].Timestamp)
+	assert.Equal(t, time.Unix(1710340100, 0).UTC(), entries[1].Timestamp)
+	assert.Equal(t, time.Unix(1710340100, 0).Add(time.Millisecond).UTC(), entries[2].Timestamp)
+}
+
+func TestParseZshHistory(t *testing.T) {
+	history := ": 1710340059:3;make test\n: 1710340100:0;for f in *; do\\\n  echo $f\\\ndone\nplain command\n: 1710340200:0;echo caf\x83\xa3\n"
+
+	entries, err := ParseZshHistory(strings.NewReader(history), testImportOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"make test", "for f in *; do\n  echo $f\ndone", "plain command", "echo caf\x83"}, commands(entries))
+	assert.Equal(t, time.Unix(1710340059, 0).UTC(), entries[0].Timestamp)
+	assert.Equal(t, int64(3*time.Second), entries[0].Duration)
+	assert.Equal(t, time.Unix(1710340100, 0).Add(time.Millisecond).UTC(), entries[2].Timestamp)
+}
+
+func TestParseFishHistory(t *testing.T) {
+	history := `- cmd: ls
+  when: 1710340059
+- cmd: echo "a\nb" \\ c
+  when: 1710340100
+  paths:
+    - /tmp
+`
+
+	entries, err := ParseFishHistory(strings.NewReader(history), testImportOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"ls", "echo \"a\nb\" \\ c"}, commands(entries))
+	assert.Equal(t, time.Unix(1710340100, 0).UTC(), entries[1].Timestamp)
+}
+
+func TestPushHistory(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	first := testHistoryEntry("a1", "ls", time.Unix(100, 0).UTC())
+	_, client := newTestStore(t, testHistoryChain(t, decodedKey, "host-1", HistoryRecord{Entry: &first})...)
+
+	entries, err := ParseBashHistory(strings.NewReader("#200\nmake\n"), testImportOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.PushHistory(t.Context(), "token", key, "host-1", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.Verify(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.OK())
+
+	history, err := client.History(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"ls", "make"}, commands(history))
+}
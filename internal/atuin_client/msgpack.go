@@ -0,0 +1,243 @@
+package atuin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The Atuin client encodes record data with the low-level rmp msgpack functions, which use a fixed
+// marker for some values (e.g. write_u16 always writes a uint16). msgpackWriter reproduces that
+// encoding, and msgpackReader accepts any valid representation of the values it reads.
+
+var errMsgpackEOF = errors.New("msgpack: unexpected end of data")
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) writeU8(v uint8) {
+	w.buf = append(w.buf, 0xcc, v)
+}
+
+func (w *msgpackWriter) writeU16(v uint16) {
+	w.buf = append(w.buf, 0xcd)
+	w.buf = binary.BigEndian.AppendUint16(w.buf, v)
+}
+
+func (w *msgpackWriter) writeU64(v uint64) {
+	w.buf = append(w.buf, 0xcf)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+// writeSint writes a signed integer in its most compact representation.
+func (w *msgpackWriter) writeSint(v int64) {
+	switch {
+	case v >= -32 && v <= 127:
+		w.buf = append(w.buf, byte(int8(v)))
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		w.buf = append(w.buf, 0xd0, byte(int8(v)))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		w.buf = append(w.buf, 0xd1)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(int16(v)))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(int32(v)))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v))
+	}
+}
+
+func (w *msgpackWriter) writeNil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+func (w *msgpackWriter) writeArrayLen(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xdc)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdd)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+}
+
+func (w *msgpackWriter) writeStr(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xda)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdb)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeBin(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xc5)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xc6)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+type msgpackReader struct {
+	buf []byte
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if len(r.buf) < n {
+		return nil, errMsgpackEOF
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+func (r *msgpackReader) marker() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readNil consumes a nil value, and reports whether the next value was nil.
+func (r *msgpackReader) readNil() bool {
+	if len(r.buf) > 0 && r.buf[0] == 0xc0 {
+		r.buf = r.buf[1:]
+		return true
+	}
+	return false
+}
+
+func (r *msgpackReader) readInt() (int64, error) {
+	m, err := r.marker()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m <= 0x7f:
+		return int64(m), nil
+	case m >= 0xe0:
+		return int64(int8(m)), nil
+	}
+
+	sizes := map[byte]int{0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8, 0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8}
+	size, ok := sizes[m]
+	if !ok {
+		return 0, fmt.Errorf("msgpack: expected integer, got marker 0x%02x", m)
+	}
+
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	switch m {
+	case 0xcc:
+		return int64(b[0]), nil
+	case 0xcd:
+		return int64(binary.BigEndian.Uint16(b)), nil
+	case 0xce:
+		return int64(binary.BigEndian.Uint32(b)), nil
+	case 0xcf:
+		v := binary.BigEndian.Uint64(b)
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("msgpack: integer %d out of range", v)
+		}
+		return int64(v), nil
+	case 0xd0:
+		return int64(int8(b[0])), nil
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	default:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	}
+}
+
+func (r *msgpackReader) readUint() (uint64, error) {
+	v, err := r.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("msgpack: expected unsigned integer, got %d", v)
+	}
+	return uint64(v), nil
+}
+
+func (r *msgpackReader) readLen(fixMask, fixMax byte, markers [3]byte) (int, error) {
+	m, err := r.marker()
+	if err != nil {
+		return 0, err
+	}
+
+	if fixMask != 0 && m&^fixMax == fixMask {
+		return int(m & fixMax), nil
+	}
+
+	for i, size := range []int{1, 2, 4} {
+		if markers[i] == 0 || m != markers[i] {
+			continue
+		}
+
+		b, err := r.next(size)
+		if err != nil {
+			return 0, err
+		}
+
+		switch size {
+		case 1:
+			return int(b[0]), nil
+		case 2:
+			return int(binary.BigEndian.Uint16(b)), nil
+		default:
+			return int(binary.BigEndian.Uint32(b)), nil
+		}
+	}
+
+	return 0, fmt.Errorf("msgpack: unexpected marker 0x%02x", m)
+}
+
+func (r *msgpackReader) readArrayLen() (int, error) {
+	return r.readLen(0x90, 0x0f, [3]byte{0, 0xdc, 0xdd})
+}
+
+func (r *msgpackReader) readStr() (string, error) {
+	n, err := r.readLen(0xa0, 0x1f, [3]byte{0xd9, 0xda, 0xdb})
+	if err != nil {
+		return "", err
+	}
+
+	b, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *msgpackReader) readBinLen() (int, error) {
+	return r.readLen(0, 0, [3]byte{0xc4, 0xc5, 0xc6})
+}
@@ -0,0 +1,84 @@
+package atuin
+
+import (
+	"context"
+	"errors"
+)
+
+// PurgeCount is the number of records of a single host and tag that cannot be decrypted.
+type PurgeCount struct {
+	Host          string
+	Tag           string
+	Records       int
+	Undecryptable int
+}
+
+type PurgeResult struct {
+	Counts []PurgeCount
+	// Purged is the total number of records that were deleted from the server, zero on a dry run.
+	Purged int
+}
+
+// Undecryptable returns the total number of records that cannot be decrypted.
+func (r *PurgeResult) Undecryptable() int {
+	total := 0
+	for _, c := range r.Counts {
+		total += c.Undecryptable
+	}
+	return total
+}
+
+// Purge finds the records that cannot be decrypted with the base64 encoded key, and deletes them from
+// the server, like `atuin store purge` followed by `atuin store push --force`. On a dry run, the
+// records are only counted. The remaining records keep their idx, so purged records leave gaps in
+// their record chains.
+func (c *AtuinClient) Purge(ctx context.Context, sessionToken, key string, dryRun bool) (*PurgeResult, error) {
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{}
+	var keep []Record
+
+	for _, chain := range status.Chains() {
+		records, err := c.Records(ctx, sessionToken, chain.Host, chain.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		count := PurgeCount{Host: chain.Host, Tag: chain.Tag, Records: len(records)}
+
+		for i := range records {
+			_, err := DecryptRecord(&records[i], decodedKey)
+			if errors.Is(err, ErrDecryption) {
+				count.Undecryptable++
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			keep = append(keep, records[i])
+		}
+
+		result.Counts = append(result.Counts, count)
+	}
+
+	if dryRun || result.Undecryptable() == 0 {
+		return result, nil
+	}
+
+	err = c.ReplaceStore(ctx, sessionToken, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Purged = result.Undecryptable()
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package atuin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurge(t *testing.T) {
+	key, _ := GenerateEncryptionKey()
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	records := append(testChain(t, decodedKey, 0, 1), testChain(t, testKey(t), 2, 3)...)
+	store, client := newTestStore(t, records...)
+
+	result, err := client.Purge(t.Context(), "token", key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []PurgeCount{{Host: testRecord(0).Host.ID, Tag: "history", Records: 4, Undecryptable: 2}}, result.Counts)
+	assert.Equal(t, 0, result.Purged)
+	assert.Len(t, store.records, 4)
+
+	result, err = client.Purge(t.Context(), "token", key, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, result.Purged)
+	assert.Equal(t, records[:2], store.records)
+}
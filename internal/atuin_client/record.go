@@ -0,0 +1,292 @@
+package atuin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// recordPageSize is the number of records requested per page when downloading a record chain.
+const recordPageSize = 100
+
+type Host struct {
+	ID string `json:"id"`
+}
+
+type EncryptedData struct {
+	Data                 string `json:"data"`
+	ContentEncryptionKey string `json:"content_encryption_key"`
+}
+
+// Record is a single entry of the Atuin record store. Records form a chain per host and tag, in
+// which idx increases by one for every record.
+type Record struct {
+	ID        string        `json:"id"`
+	Idx       uint64        `json:"idx"`
+	Host      Host          `json:"host"`
+	Timestamp uint64        `json:"timestamp"`
+	Version   string        `json:"version"`
+	Tag       string        `json:"tag"`
+	Data      EncryptedData `json:"data"`
+}
+
+// RecordStatus holds the last idx of every record chain, indexed by host and tag.
+type RecordStatus struct {
+	Hosts map[string]map[string]uint64 `json:"hosts"`
+}
+
+// Chain identifies a record chain and its last idx.
+type Chain struct {
+	Host    string
+	Tag     string
+	LastIdx uint64
+}
+
+// Chains returns every record chain in the status, sorted by host and tag.
+func (s *RecordStatus) Chains() []Chain {
+	var chains []Chain
+	for host, tags := range s.Hosts {
+		for tag, idx := range tags {
+			chains = append(chains, Chain{Host: host, Tag: tag, LastIdx: idx})
+		}
+	}
+
+	sort.Slice(chains, func(i, j int) bool {
+		if chains[i].Host != chains[j].Host {
+			return chains[i].Host < chains[j].Host
+		}
+		return chains[i].Tag < chains[j].Tag
+	})
+
+	return chains
+}
+
+func (c *AtuinClient) newAuthorizedRequest(ctx context.Context, method, path, sessionToken string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonValue, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(jsonValue)
+	}
+
+	request, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", "Token "+sessionToken)
+
+	return request, nil
+}
+
+// responseError turns a non-successful response into an error, using the reason reported by the server if there is one.
+func responseError(resp *http.Response) error {
+	var e ErrorMessage
+	err := json.NewDecoder(resp.Body).Decode(&e)
+	if err != nil || e.Reason == "" {
+		return &APIError{StatusCode: resp.StatusCode, Reason: fmt.Sprintf("unexpected response: %s", resp.Status)}
+	}
+
+	return &APIError{StatusCode: resp.StatusCode, Reason: e.Reason}
+}
+
+func (c *AtuinClient) RecordStatus(ctx context.Context, sessionToken string) (*RecordStatus, error) {
+	request, err := c.newAuthorizedRequest(ctx, "GET", "/api/v0/record", sessionToken, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var s RecordStatus
+	err = json.NewDecoder(resp.Body).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// NextRecords returns at most count records of a chain, starting at idx start.
+func (c *AtuinClient) NextRecords(ctx context.Context, sessionToken, host, tag string, start uint64, count int) ([]Record, error) {
+	query := url.Values{}
+	query.Set("host", host)
+	query.Set("tag", tag)
+	query.Set("start", strconv.FormatUint(start, 10))
+	query.Set("count", strconv.Itoa(count))
+
+	request, err := c.newAuthorizedRequest(ctx, "GET", "/api/v0/record/next?"+query.Encode(), sessionToken, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var records []Record
+	err = json.NewDecoder(resp.Body).Decode(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Records downloads the complete record chain of a host and tag.
+func (c *AtuinClient) Records(ctx context.Context, sessionToken, host, tag string) ([]Record, error) {
+	var records []Record
+
+	err := c.recordPages(ctx, sessionToken, host, tag, func(page []Record) error {
+		records = append(records, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// recordPages downloads the record chain of a host and tag page by page, and calls fn with every page.
+func (c *AtuinClient) recordPages(ctx context.Context, sessionToken, host, tag string, fn func(page []Record) error) error {
+	var start uint64
+
+	for {
+		page, err := c.NextRecords(ctx, sessionToken, host, tag, start, recordPageSize)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+
+		if len(page) < recordPageSize {
+			return nil
+		}
+
+		start = page[len(page)-1].Idx + 1
+	}
+}
+
+func (c *AtuinClient) PushRecords(ctx context.Context, sessionToken string, records []Record) error {
+	request, err := c.newAuthorizedRequest(ctx, "POST", "/api/v0/record", sessionToken, records)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error pushing records: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+// DeleteStore deletes all records of the user from the server.
+func (c *AtuinClient) DeleteStore(ctx context.Context, sessionToken string) error {
+	request, err := c.newAuthorizedRequest(ctx, "DELETE", "/api/v0/store", sessionToken, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error deleting record store: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+// PartialReplaceError is returned by ReplaceStore when the record store was deleted, but not all
+// records were uploaded in its place. The records from index Uploaded on are lost from the server.
+type PartialReplaceError struct {
+	Uploaded int
+	Lost     []Record
+	Err      error
+}
+
+func (e *PartialReplaceError) Error() string {
+	first, last := e.Lost[0], e.Lost[len(e.Lost)-1]
+	return fmt.Sprintf("record store was deleted, but records %d to %d of %d were not uploaded and are lost from the server, "+
+		"from record %s (host %s, tag %s, idx %d) to record %s (host %s, tag %s, idx %d): %s",
+		e.Uploaded+1, e.Uploaded+len(e.Lost), e.Uploaded+len(e.Lost),
+		first.ID, first.Host.ID, first.Tag, first.Idx, last.ID, last.Host.ID, last.Tag, last.Idx, e.Err)
+}
+
+func (e *PartialReplaceError) Unwrap() error {
+	return e.Err
+}
+
+// ReplaceStore deletes all records of the user from the server, and uploads the given records in their place.
+// When an upload fails after the store was deleted, the error is a *PartialReplaceError.
+func (c *AtuinClient) ReplaceStore(ctx context.Context, sessionToken string, records []Record) error {
+	err := c.DeleteStore(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(records); start += recordPageSize {
+		end := min(start+recordPageSize, len(records))
+
+		err = c.PushRecords(ctx, sessionToken, records[start:end])
+		if err != nil {
+			return &PartialReplaceError{Uploaded: start, Lost: records[start:], Err: err}
+		}
+	}
+
+	return nil
+}
+
+// AllRecords downloads every record chain of the user.
+func (c *AtuinClient) AllRecords(ctx context.Context, sessionToken string) ([]Record, error) {
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, chain := range status.Chains() {
+		chainRecords, err := c.Records(ctx, sessionToken, chain.Host, chain.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, chainRecords...)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,170 @@
+package atuin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testStore is an in-memory implementation of the record store endpoints of the Atuin server.
+type testStore struct {
+	mu      sync.Mutex
+	records []Record
+
+	// maxPushes is the number of pushes that succeed, before every push fails. Zero means no limit.
+	maxPushes int
+	pushes    int
+}
+
+func newTestStore(t *testing.T, records ...Record) (*testStore, *AtuinClient) {
+	t.Helper()
+
+	store := &testStore{records: records}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v0/record", store.status)
+	mux.HandleFunc("POST /api/v0/record", store.push)
+	mux.HandleFunc("GET /api/v0/record/next", store.next)
+	mux.HandleFunc("DELETE /api/v0/store", store.delete)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return store, NewAtuinClient(server.URL, WithRateLimit(1000, 1000))
+}
+
+func (s *testStore) status(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := RecordStatus{Hosts: map[string]map[string]uint64{}}
+	for _, record := range s.records {
+		if status.Hosts[record.Host.ID] == nil {
+			status.Hosts[record.Host.ID] = map[string]uint64{}
+		}
+		if idx, ok := status.Hosts[record.Host.ID][record.Tag]; !ok || record.Idx > idx {
+			status.Hosts[record.Host.ID][record.Tag] = record.Idx
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func (s *testStore) push(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []Record
+	if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if s.maxPushes > 0 && s.pushes == s.maxPushes {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.pushes++
+
+	s.records = append(s.records, records...)
+}
+
+func (s *testStore) next(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	start, _ := strconv.ParseUint(query.Get("start"), 10, 64)
+	count, _ := strconv.Atoi(query.Get("count"))
+
+	records := []Record{}
+	for _, record := range s.records {
+		if record.Host.ID == query.Get("host") && record.Tag == query.Get("tag") && record.Idx >= start {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Idx < records[j].Idx })
+	if len(records) > count {
+		records = records[:count]
+	}
+
+	_ = json.NewEncoder(w).Encode(records)
+}
+
+func (s *testStore) delete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = nil
+}
+
+func TestRecordsPagination(t *testing.T) {
+	key := testKey(t)
+
+	var indices []uint64
+	for idx := uint64(0); idx < 2*recordPageSize+5; idx++ {
+		indices = append(indices, idx)
+	}
+
+	_, client := newTestStore(t, testChain(t, key, indices...)...)
+
+	records, err := client.Records(t.Context(), "token", testRecord(0).Host.ID, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, records, len(indices))
+}
+
+func TestReplaceStorePartialFailure(t *testing.T) {
+	key := testKey(t)
+
+	var indices []uint64
+	for idx := uint64(0); idx < 2*recordPageSize+5; idx++ {
+		indices = append(indices, idx)
+	}
+	records := testChain(t, key, indices...)
+
+	store, client := newTestStore(t, testChain(t, key, 0)...)
+	store.maxPushes = 1
+
+	err := client.ReplaceStore(t.Context(), "token", records)
+
+	var partial *PartialReplaceError
+	if assert.ErrorAs(t, err, &partial) {
+		assert.Equal(t, recordPageSize, partial.Uploaded)
+		assert.Equal(t, records[recordPageSize:], partial.Lost)
+		assert.ErrorContains(t, err, "records 101 to 205 of 205")
+		assert.ErrorContains(t, err, fmt.Sprintf("idx %d) to record %s (host %s, tag history, idx 204)", recordPageSize, records[204].ID, records[204].Host.ID))
+	}
+	assert.Equal(t, records[:recordPageSize], store.records)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedKey, _ := DecodeEncryptionKey(key)
+
+	_, client := newTestStore(t, testChain(t, decodedKey, 0, 1, 3)...)
+
+	result, err := client.Verify(t.Context(), "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, result.OK())
+	if assert.Len(t, result.Chains, 1) {
+		assert.Equal(t, []IdxRange{{Start: 2, End: 2}}, result.Chains[0].Gaps)
+	}
+}
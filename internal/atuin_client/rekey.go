@@ -0,0 +1,59 @@
+package atuin
+
+import (
+	"context"
+	"fmt"
+)
+
+// Rekey re-encrypts every record of the user from oldKey to newKey, both base64 encoded, and replaces
+// the records on the server. Like `atuin store rekey`, the content encryption key of every record is
+// re-wrapped with the new key. Every record is decrypted with the old key first, and nothing is changed
+// on the server if any of them fails to decrypt.
+//
+// Callers that must not lose the new key when the upload fails halfway should use ReencryptRecords and
+// ReplaceStore, and save the new key in between.
+func (c *AtuinClient) Rekey(ctx context.Context, sessionToken, oldKey, newKey string) error {
+	records, err := c.ReencryptRecords(ctx, sessionToken, oldKey, newKey)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	return c.ReplaceStore(ctx, sessionToken, records)
+}
+
+// ReencryptRecords downloads every record of the user, and re-encrypts it from oldKey to newKey, both
+// base64 encoded. Nothing is changed on the server.
+func (c *AtuinClient) ReencryptRecords(ctx context.Context, sessionToken, oldKey, newKey string) ([]Record, error) {
+	decodedOldKey, err := DecodeEncryptionKey(oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old encryption key: %w", err)
+	}
+
+	decodedNewKey, err := DecodeEncryptionKey(newKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new encryption key: %w", err)
+	}
+
+	records, err := c.AllRecords(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		r := &records[i]
+
+		if _, err := DecryptRecord(r, decodedOldKey); err != nil {
+			return nil, fmt.Errorf("record %s (host %s, tag %s, idx %d) cannot be decrypted with the old key: %w", r.ID, r.Host.ID, r.Tag, r.Idx, err)
+		}
+
+		if err := ReencryptRecord(r, decodedOldKey, decodedNewKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,44 @@
+package atuin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRekey(t *testing.T) {
+	oldKey, _ := GenerateEncryptionKey()
+	newKey, _ := GenerateEncryptionKey()
+	decodedOldKey, _ := DecodeEncryptionKey(oldKey)
+	decodedNewKey, _ := DecodeEncryptionKey(newKey)
+
+	store, client := newTestStore(t, testChain(t, decodedOldKey, 0, 1, 2)...)
+
+	err := client.Rekey(t.Context(), "token", oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, store.records, 3) {
+		for i := range store.records {
+			_, err := DecryptRecord(&store.records[i], decodedNewKey)
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestRekeyWithUndecryptableRecord(t *testing.T) {
+	oldKey, _ := GenerateEncryptionKey()
+	newKey, _ := GenerateEncryptionKey()
+	decodedOldKey, _ := DecodeEncryptionKey(oldKey)
+
+	records := append(testChain(t, decodedOldKey, 0, 1), testChain(t, testKey(t), 2)...)
+	store, client := newTestStore(t, records...)
+
+	err := client.Rekey(t.Context(), "token", oldKey, newKey)
+	assert.True(t, errors.Is(err, ErrDecryption))
+
+	// The server copy must be left untouched
+	assert.Equal(t, records, store.records)
+}
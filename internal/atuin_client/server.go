@@ -0,0 +1,38 @@
+package atuin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ServerInfo is what an Atuin server reports about itself at the root of the API.
+type ServerInfo struct {
+	Homage  string `json:"homage"`
+	Version string `json:"version"`
+}
+
+// ServerInfo returns the version of the Atuin server. It does not require a login.
+func (c *AtuinClient) ServerInfo(ctx context.Context) (ServerInfo, error) {
+	request, err := c.newRequest(ctx, "GET", "/", nil)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ServerInfo{}, responseError(resp)
+	}
+
+	var info ServerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return ServerInfo{}, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,21 @@
+package atuin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/atuin/", r.URL.Path)
+		_, _ = w.Write([]byte(`{"homage": "An homage to an homage to fish", "version": "18.4.0"}`))
+	}))
+	defer server.Close()
+
+	info, err := NewAtuinClient(server.URL + "/atuin").ServerInfo(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, "18.4.0", info.Version)
+}
@@ -0,0 +1,118 @@
+package atuin
+
+import (
+	"context"
+	"sort"
+)
+
+// IdxRange is an inclusive range of record indices.
+type IdxRange struct {
+	Start uint64
+	End   uint64
+}
+
+type UndecryptableRecord struct {
+	ID    string
+	Idx   uint64
+	Error string
+}
+
+// ChainVerification is the result of verifying the record chain of a single host and tag.
+type ChainVerification struct {
+	Host    string
+	Tag     string
+	LastIdx uint64
+	Records int
+	// Gaps lists the ranges of indices between 0 and LastIdx for which no record exists.
+	Gaps []IdxRange
+	// Duplicates lists the indices of records whose idx or id was already seen earlier in the chain.
+	Duplicates    []uint64
+	Undecryptable []UndecryptableRecord
+}
+
+func (c *ChainVerification) OK() bool {
+	return len(c.Gaps) == 0 && len(c.Duplicates) == 0 && len(c.Undecryptable) == 0
+}
+
+type VerifyResult struct {
+	Chains []ChainVerification
+}
+
+func (r *VerifyResult) OK() bool {
+	for i := range r.Chains {
+		if !r.Chains[i].OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify walks every record chain of the user, and checks that the chain is contiguous and that every
+// record can be decrypted with the given base64 encoded encryption key.
+func (c *AtuinClient) Verify(ctx context.Context, sessionToken, key string) (*VerifyResult, error) {
+	decodedKey, err := DecodeEncryptionKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := c.RecordStatus(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{}
+
+	for _, chain := range status.Chains() {
+		records, err := c.Records(ctx, sessionToken, chain.Host, chain.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Chains = append(result.Chains, VerifyChain(chain.Host, chain.Tag, chain.LastIdx, records, decodedKey))
+	}
+
+	return result, nil
+}
+
+// VerifyChain checks a downloaded record chain for gaps, duplicates and records that cannot be decrypted.
+func VerifyChain(host, tag string, lastIdx uint64, records []Record, key []byte) ChainVerification {
+	v := ChainVerification{Host: host, Tag: tag, LastIdx: lastIdx, Records: len(records)}
+
+	sorted := make([]Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Idx < sorted[j].Idx })
+
+	seenIdx := map[uint64]bool{}
+	seenID := map[string]bool{}
+
+	for i := range sorted {
+		r := &sorted[i]
+
+		if seenIdx[r.Idx] || seenID[r.ID] {
+			v.Duplicates = append(v.Duplicates, r.Idx)
+		}
+		seenIdx[r.Idx] = true
+		seenID[r.ID] = true
+
+		if _, err := DecryptRecord(r, key); err != nil {
+			v.Undecryptable = append(v.Undecryptable, UndecryptableRecord{ID: r.ID, Idx: r.Idx, Error: err.Error()})
+		}
+	}
+
+	var gapStart uint64
+	inGap := false
+	for idx := uint64(0); idx <= lastIdx; idx++ {
+		if !seenIdx[idx] && !inGap {
+			gapStart, inGap = idx, true
+		}
+		if seenIdx[idx] && inGap {
+			v.Gaps = append(v.Gaps, IdxRange{Start: gapStart, End: idx - 1})
+			inGap = false
+		}
+	}
+	if inGap {
+		v.Gaps = append(v.Gaps, IdxRange{Start: gapStart, End: lastIdx})
+	}
+
+	return v
+}
@@ -0,0 +1,63 @@
+package atuin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testChain(t *testing.T, key []byte, indices ...uint64) []Record {
+	t.Helper()
+
+	var records []Record
+	for _, idx := range indices {
+		r := testRecord(idx)
+		r.ID = fmt.Sprintf("0190b6e2-5b8a-7c3e-9a1d-%012d", idx)
+		if err := EncryptRecord(&r, []byte("ankh-morpork"), key); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestVerifyChainOK(t *testing.T) {
+	key := testKey(t)
+
+	v := VerifyChain("host", "history", 3, testChain(t, key, 0, 1, 2, 3), key)
+
+	assert.True(t, v.OK())
+	assert.Equal(t, 4, v.Records)
+}
+
+func TestVerifyChainGaps(t *testing.T) {
+	key := testKey(t)
+
+	v := VerifyChain("host", "history", 7, testChain(t, key, 0, 3, 4, 6), key)
+
+	assert.False(t, v.OK())
+	assert.Equal(t, []IdxRange{{Start: 1, End: 2}, {Start: 5, End: 5}, {Start: 7, End: 7}}, v.Gaps)
+}
+
+func TestVerifyChainDuplicates(t *testing.T) {
+	key := testKey(t)
+
+	v := VerifyChain("host", "history", 2, testChain(t, key, 0, 1, 1, 2), key)
+
+	assert.False(t, v.OK())
+	assert.Empty(t, v.Gaps)
+	assert.Equal(t, []uint64{1}, v.Duplicates)
+}
+
+func TestVerifyChainUndecryptable(t *testing.T) {
+	key := testKey(t)
+	records := append(testChain(t, key, 0, 1), testChain(t, testKey(t), 2)...)
+
+	v := VerifyChain("host", "history", 2, records, key)
+
+	assert.False(t, v.OK())
+	if assert.Len(t, v.Undecryptable, 1) {
+		assert.Equal(t, uint64(2), v.Undecryptable[0].Idx)
+	}
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ datasource.DataSource              = &AtuinActivity{}
+	_ datasource.DataSourceWithConfigure = &AtuinActivity{}
+)
+
+func NewAtuinActivity() datasource.DataSource {
+	return &AtuinActivity{}
+}
+
+// AtuinActivity defines the data source implementation.
+type AtuinActivity struct {
+	client *atuin.AtuinClient
+}
+
+// AtuinActivityModel describes the data source data model.
+type AtuinActivityModel struct {
+	Username   types.String               `tfsdk:"username"`
+	Password   types.String               `tfsdk:"password"`
+	Focus      types.String               `tfsdk:"focus"`
+	Year       types.Int64                `tfsdk:"year"`
+	Month      types.Int64                `tfsdk:"month"`
+	TotalCount types.Int64                `tfsdk:"total_count"`
+	Periods    []AtuinActivityPeriodModel `tfsdk:"periods"`
+}
+
+type AtuinActivityPeriodModel struct {
+	Period types.Int64 `tfsdk:"period"`
+	Count  types.Int64 `tfsdk:"count"`
+}
+
+func (d *AtuinActivity) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_activity"
+}
+
+func (d *AtuinActivity) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Aggregated shell history activity of an Atuin user, as reported by the activity calendar of the Atuin server. The history is not downloaded or decrypted.",
+
+		Attributes: map[string]schema.Attribute{
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Username of Atuin user. Defaults to the user of the provider `auth` block.",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "Password of Atuin user. Defaults to the user of the provider `auth` block.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"focus": schema.StringAttribute{
+				MarkdownDescription: "Granularity of the activity: `year` for the activity per year, `month` for the activity per month of `year`, or `day` for the activity per day of `month`",
+				Required:            true,
+			},
+			"year": schema.Int64Attribute{
+				MarkdownDescription: "Year of the `month` and `day` focus. Defaults to the current year.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"month": schema.Int64Attribute{
+				MarkdownDescription: "Month of the `day` focus, from 1 to 12. Defaults to the current month.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"total_count": schema.Int64Attribute{
+				MarkdownDescription: "Total number of history entries in all periods",
+				Computed:            true,
+			},
+			"periods": schema.ListNestedAttribute{
+				MarkdownDescription: "Number of history entries per period, sorted by period",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"period": schema.Int64Attribute{
+							MarkdownDescription: "The year, month or day of the period",
+							Computed:            true,
+						},
+						"count": schema.Int64Attribute{
+							MarkdownDescription: "Number of history entries in the period",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *AtuinActivity) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*atuin.AtuinClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *atuin.AtuinClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *AtuinActivity) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx, span := startSpan(ctx, d.client, "atuin_activity.Read")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
+	var data AtuinActivityModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	focus := atuin.CalendarFocus(data.Focus.ValueString())
+	if !slices.Contains(atuin.CalendarFocuses, focus) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("focus"),
+			"Invalid Activity Focus",
+			fmt.Sprintf("The focus must be one of year, month or day, got: %q.", focus),
+		)
+	}
+
+	now := time.Now()
+
+	if data.Year.IsNull() {
+		data.Year = types.Int64Value(int64(now.Year()))
+	}
+
+	if data.Month.IsNull() {
+		data.Month = types.Int64Value(int64(now.Month()))
+	}
+
+	if month := data.Month.ValueInt64(); month < 1 || month > 12 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("month"),
+			"Invalid Activity Month",
+			fmt.Sprintf("The month must be between 1 and 12, got: %d.", month),
+		)
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sessionToken, diags := resolveSessionToken(ctx, d.client, data.Username, data.Password)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	calendar, err := d.client.Calendar(ctx, sessionToken, focus, int(data.Year.ValueInt64()), int(data.Month.ValueInt64()))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read activity, got error: %s", err))
+		return
+	}
+
+	var total int64
+	data.Periods = []AtuinActivityPeriodModel{}
+
+	for _, period := range calendar {
+		total += int64(period.Count)
+		data.Periods = append(data.Periods, AtuinActivityPeriodModel{
+			Period: types.Int64Value(int64(period.Period)),
+			Count:  types.Int64Value(int64(period.Count)),
+		})
+	}
+
+	data.TotalCount = types.Int64Value(total)
+
+	tflog.Trace(ctx, "read Atuin activity", map[string]any{"focus": string(focus), "total_count": total})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
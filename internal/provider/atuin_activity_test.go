@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccAtuinActivityDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A freshly created user has no activity yet
+			{
+				Config: testAccAtuinActivityDataSourceConfig("carrot", "pa$$word", "carrot@example.com"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.atuin_activity.test", "total_count", "0"),
+					resource.TestCheckResourceAttrSet("data.atuin_activity.test", "year"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAtuinActivityDataSourceConfig(username, password, email string) string {
+	return fmt.Sprintf(`
+resource "atuin_user" "test" {
+  username = %[1]q
+  password = %[2]q
+  email    = %[3]q
+}
+
+data "atuin_activity" "test" {
+  username = atuin_user.test.full_username
+  password = atuin_user.test.password
+  focus    = "month"
+}
+`, username, password, email)
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/BurntSushi/toml"
+)
+
+// atuinClientConfig is the part of the configuration file of the Atuin client that the provider uses.
+type atuinClientConfig struct {
+	SyncAddress string `toml:"sync_address"`
+	DataDir     string `toml:"data_dir"`
+	SessionPath string `toml:"session_path"`
+	KeyPath     string `toml:"key_path"`
+}
+
+// atuinConfigDir returns the configuration directory of the Atuin client, the same way the client
+// resolves it: ATUIN_CONFIG_DIR, then $XDG_CONFIG_HOME/atuin, then ~/.config/atuin.
+func atuinConfigDir() (string, error) {
+	if dir := os.Getenv("ATUIN_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "atuin"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "atuin"), nil
+}
+
+// readAtuinClientConfig reads config.toml from the configuration directory of the Atuin client. It
+// returns a nil config without error when the file does not exist.
+func readAtuinClientConfig() (*atuinClientConfig, string, error) {
+	dir, err := atuinConfigDir()
+	if err != nil {
+		return nil, "", err
+	}
+
+	file := filepath.Join(dir, "config.toml")
+
+	var config atuinClientConfig
+	if _, err := toml.DecodeFile(file, &config); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, file, nil
+		}
+		return nil, file, err
+	}
+
+	return &config, file, nil
+}
+
+// atuinLocalLogin is the login of the Atuin client on this machine, as written by `atuin login`.
+type atuinLocalLogin struct {
+	SessionToken string
+	// Key is the base64 encoded encryption key, or empty when the key file does not exist.
+	Key string
+}
+
+// atuinDataDir returns the data directory of the Atuin client: ATUIN_DATA_DIR, then the data_dir of the
+// client configuration, then $XDG_DATA_HOME/atuin, then ~/.local/share/atuin.
+func atuinDataDir(config *atuinClientConfig) (string, error) {
+	if dir := os.Getenv("ATUIN_DATA_DIR"); dir != "" {
+		return expandHome(dir)
+	}
+
+	if config != nil && config.DataDir != "" {
+		return expandHome(config.DataDir)
+	}
+
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "atuin"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "atuin"), nil
+}
+
+// atuinLoginPath returns the path of the session or key file of the Atuin client, which may be set by
+// an environment variable or the client configuration, and otherwise defaults to name in the data directory.
+func atuinLoginPath(env, configured, name string, config *atuinClientConfig) (string, error) {
+	if p := os.Getenv(env); p != "" {
+		return expandHome(p)
+	}
+
+	if configured != "" {
+		return expandHome(configured)
+	}
+
+	dir, err := atuinDataDir(config)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// readAtuinLocalLogin reads the session token and encryption key of the Atuin client on this machine.
+func readAtuinLocalLogin(config *atuinClientConfig) (*atuinLocalLogin, error) {
+	if config == nil {
+		config = &atuinClientConfig{}
+	}
+
+	sessionPath, err := atuinLoginPath("ATUIN_SESSION_PATH", config.SessionPath, "session", config)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := os.ReadFile(sessionPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.New("no session file found at " + sessionPath + ", run `atuin login` first")
+		}
+		return nil, err
+	}
+
+	login := &atuinLocalLogin{SessionToken: strings.TrimSpace(string(session))}
+
+	keyPath, err := atuinLoginPath("ATUIN_KEY_PATH", config.KeyPath, "key", config)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return login, nil
+		}
+		return nil, err
+	}
+
+	login.Key, err = atuin.DecodeKeyFile(string(key))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+
+	return login, nil
+}
+
+// expandHome expands a leading ~ in a path, as the Atuin client does for its configured paths.
+func expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~")), nil
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtuinConfigDir(t *testing.T) {
+	t.Setenv("ATUIN_CONFIG_DIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+	dir, err := atuinConfigDir()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/xdg", "atuin"), dir)
+
+	t.Setenv("ATUIN_CONFIG_DIR", "/atuin")
+
+	dir, err = atuinConfigDir()
+	assert.NoError(t, err)
+	assert.Equal(t, "/atuin", dir)
+}
+
+func TestReadAtuinClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ATUIN_CONFIG_DIR", dir)
+
+	config, _, err := readAtuinClientConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, config, "missing config file")
+
+	contents := "# sync_address = \"https://api.atuin.sh\"\nsync_address = \"https://atuin.example.com\"\n\n[sync]\nrecords = true\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, file, err := readAtuinClientConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "config.toml"), file)
+	if assert.NotNil(t, config) {
+		assert.Equal(t, "https://atuin.example.com", config.SyncAddress)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("sync_address = "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = readAtuinClientConfig()
+	assert.Error(t, err)
+}
+
+func TestReadAtuinLocalLogin(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ATUIN_DATA_DIR", dir)
+	t.Setenv("ATUIN_SESSION_PATH", "")
+	t.Setenv("ATUIN_KEY_PATH", "")
+
+	_, err := readAtuinLocalLogin(nil)
+	assert.ErrorContains(t, err, "atuin login")
+
+	if err := os.WriteFile(filepath.Join(dir, "session"), []byte("local-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := readAtuinLocalLogin(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &atuinLocalLogin{SessionToken: "local-token"}, login)
+
+	key, err := atuin.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := atuin.EncodeKeyFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key path of the client configuration is used instead of the data directory
+	keyPath := filepath.Join(t.TempDir(), "atuin.key")
+	if err := os.WriteFile(keyPath, []byte(keyFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	login, err = readAtuinLocalLogin(&atuinClientConfig{KeyPath: keyPath})
+	assert.NoError(t, err)
+	assert.Equal(t, &atuinLocalLogin{SessionToken: "local-token", Key: key}, login)
+}
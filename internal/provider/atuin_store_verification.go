@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ datasource.DataSource              = &AtuinStoreVerification{}
+	_ datasource.DataSourceWithConfigure = &AtuinStoreVerification{}
+)
+
+func NewAtuinStoreVerification() datasource.DataSource {
+	return &AtuinStoreVerification{}
+}
+
+// AtuinStoreVerification defines the data source implementation.
+type AtuinStoreVerification struct {
+	client *atuin.AtuinClient
+}
+
+// AtuinStoreVerificationModel describes the data source data model.
+type AtuinStoreVerificationModel struct {
+	Username    types.String            `tfsdk:"username"`
+	Password    types.String            `tfsdk:"password"`
+	Base64Key   types.String            `tfsdk:"base64_key"`
+	OK          types.Bool              `tfsdk:"ok"`
+	RecordCount types.Int64             `tfsdk:"record_count"`
+	Chains      []AtuinRecordChainModel `tfsdk:"chains"`
+}
+
+type AtuinRecordChainModel struct {
+	Host          types.String                    `tfsdk:"host"`
+	Tag           types.String                    `tfsdk:"tag"`
+	LastIdx       types.Int64                     `tfsdk:"last_idx"`
+	RecordCount   types.Int64                     `tfsdk:"record_count"`
+	Gaps          []AtuinIdxRangeModel            `tfsdk:"gaps"`
+	Duplicates    []types.Int64                   `tfsdk:"duplicates"`
+	Undecryptable []AtuinUndecryptableRecordModel `tfsdk:"undecryptable"`
+}
+
+type AtuinIdxRangeModel struct {
+	Start types.Int64 `tfsdk:"start"`
+	End   types.Int64 `tfsdk:"end"`
+}
+
+type AtuinUndecryptableRecordModel struct {
+	ID    types.String `tfsdk:"id"`
+	Idx   types.Int64  `tfsdk:"idx"`
+	Error types.String `tfsdk:"error"`
+}
+
+func (d *AtuinStoreVerification) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_store_verification"
+}
+
+func (d *AtuinStoreVerification) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Verifies the integrity of the record store of an Atuin user: every record chain must be contiguous, and every record must decrypt with the given key.",
+
+		Attributes: map[string]schema.Attribute{
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Username of Atuin user. Defaults to the user of the provider `auth` block.",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "Password of Atuin user. Defaults to the user of the provider `auth` block.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"base64_key": schema.StringAttribute{
+				MarkdownDescription: "Base64 encoded encryption key of Atuin user. Defaults to the key of the local Atuin login, when the provider `auth` block uses it and no username and password are set.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"ok": schema.BoolAttribute{
+				MarkdownDescription: "Whether all record chains are contiguous, free of duplicates and decryptable",
+				Computed:            true,
+			},
+			"record_count": schema.Int64Attribute{
+				MarkdownDescription: "Total number of records in the record store",
+				Computed:            true,
+			},
+			"chains": schema.ListNestedAttribute{
+				MarkdownDescription: "Verification result per host and tag",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"host": schema.StringAttribute{
+							MarkdownDescription: "Host id of the record chain",
+							Computed:            true,
+						},
+						"tag": schema.StringAttribute{
+							MarkdownDescription: "Tag of the record chain",
+							Computed:            true,
+						},
+						"last_idx": schema.Int64Attribute{
+							MarkdownDescription: "Last idx of the record chain, as reported by the server",
+							Computed:            true,
+						},
+						"record_count": schema.Int64Attribute{
+							MarkdownDescription: "Number of records in the record chain",
+							Computed:            true,
+						},
+						"gaps": schema.ListNestedAttribute{
+							MarkdownDescription: "Inclusive ranges of indices that are missing from the record chain",
+							Computed:            true,
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"start": schema.Int64Attribute{Computed: true},
+									"end":   schema.Int64Attribute{Computed: true},
+								},
+							},
+						},
+						"duplicates": schema.ListAttribute{
+							MarkdownDescription: "Indices of records whose idx or id occurs more than once",
+							ElementType:         types.Int64Type,
+							Computed:            true,
+						},
+						"undecryptable": schema.ListNestedAttribute{
+							MarkdownDescription: "Records that cannot be decrypted with the given key",
+							Computed:            true,
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"id":    schema.StringAttribute{Computed: true},
+									"idx":   schema.Int64Attribute{Computed: true},
+									"error": schema.StringAttribute{Computed: true},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *AtuinStoreVerification) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*atuin.AtuinClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *atuin.AtuinClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *AtuinStoreVerification) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx, span := startSpan(ctx, d.client, "atuin_store_verification.Read")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
+	var data AtuinStoreVerificationModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sessionToken, diags := resolveSessionToken(ctx, d.client, data.Username, data.Password)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	key := data.Base64Key.ValueString()
+	if data.Base64Key.IsNull() && data.Username.IsNull() {
+		key = d.client.EncryptionKey()
+	}
+
+	if key == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("base64_key"),
+			"Missing Encryption Key",
+			"Set the base64_key, or use the local Atuin login in the auth block of the provider.",
+		)
+		return
+	}
+
+	result, err := d.client.Verify(ctx, sessionToken, key)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to verify record store, got error: %s", err))
+		return
+	}
+
+	var recordCount int64
+	data.Chains = []AtuinRecordChainModel{}
+
+	for _, chain := range result.Chains {
+		recordCount += int64(chain.Records)
+
+		c := AtuinRecordChainModel{
+			Host:          types.StringValue(chain.Host),
+			Tag:           types.StringValue(chain.Tag),
+			LastIdx:       types.Int64Value(int64(chain.LastIdx)),
+			RecordCount:   types.Int64Value(int64(chain.Records)),
+			Gaps:          []AtuinIdxRangeModel{},
+			Duplicates:    []types.Int64{},
+			Undecryptable: []AtuinUndecryptableRecordModel{},
+		}
+
+		for _, gap := range chain.Gaps {
+			c.Gaps = append(c.Gaps, AtuinIdxRangeModel{Start: types.Int64Value(int64(gap.Start)), End: types.Int64Value(int64(gap.End))})
+		}
+
+		for _, idx := range chain.Duplicates {
+			c.Duplicates = append(c.Duplicates, types.Int64Value(int64(idx)))
+		}
+
+		for _, r := range chain.Undecryptable {
+			c.Undecryptable = append(c.Undecryptable, AtuinUndecryptableRecordModel{
+				ID:    types.StringValue(r.ID),
+				Idx:   types.Int64Value(int64(r.Idx)),
+				Error: types.StringValue(r.Error),
+			})
+		}
+
+		data.Chains = append(data.Chains, c)
+	}
+
+	data.OK = types.BoolValue(result.OK())
+	data.RecordCount = types.Int64Value(recordCount)
+
+	tflog.Trace(ctx, "verified Atuin record store", map[string]any{"ok": result.OK(), "record_count": recordCount})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccAtuinStoreVerificationDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A freshly created user has an empty, and thereby valid, record store
+			{
+				Config: testAccAtuinStoreVerificationDataSourceConfig("vimes", "pa$$word", "vimes@example.com"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.atuin_store_verification.test", "ok", "true"),
+					resource.TestCheckResourceAttr("data.atuin_store_verification.test", "record_count", "0"),
+					resource.TestCheckResourceAttr("data.atuin_store_verification.test", "chains.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAtuinStoreVerificationDataSourceConfig(username, password, email string) string {
+	return fmt.Sprintf(`
+resource "atuin_user" "test" {
+  username = %[1]q
+  password = %[2]q
+  email    = %[3]q
+}
+
+data "atuin_store_verification" "test" {
+  username   = atuin_user.test.full_username
+  password   = atuin_user.test.password
+  base64_key = atuin_user.test.base64_key
+}
+`, username, password, email)
+}
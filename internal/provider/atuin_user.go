@@ -3,15 +3,26 @@ package provider
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	atuin "terraform-provider-atuin/internal/atuin_client"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/tyler-smith/go-bip39"
@@ -19,10 +30,33 @@ import (
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var (
-	_ resource.Resource                = &AtuinUser{}
-	_ resource.ResourceWithImportState = &AtuinUser{}
+	_ resource.Resource                   = &AtuinUser{}
+	_ resource.ResourceWithImportState    = &AtuinUser{}
+	_ resource.ResourceWithModifyPlan     = &AtuinUser{}
+	_ resource.ResourceWithValidateConfig = &AtuinUser{}
 )
 
+// Behaviours of atuin_user when the password of the account was changed outside Terraform.
+const (
+	passwordDriftError  = "error"
+	passwordDriftWarn   = "warn"
+	passwordDriftIgnore = "ignore"
+)
+
+var passwordDriftBehaviours = []string{passwordDriftError, passwordDriftWarn, passwordDriftIgnore}
+
+// Policies of atuin_user for email changes the server cannot apply.
+const (
+	emailChangeRequiresReplace = "requires_replace"
+	emailChangeWarn            = "warn"
+	emailChangeIgnore          = "ignore"
+)
+
+var emailChangePolicies = []string{emailChangeRequiresReplace, emailChangeWarn, emailChangeIgnore}
+
+// atuinUserDefaultTimeout is how long operations on atuin_user may take, unless configured in the timeouts block.
+const atuinUserDefaultTimeout = 20 * time.Minute
+
 func NewAtuinUser() resource.Resource {
 	return &AtuinUser{}
 }
@@ -30,15 +64,27 @@ func NewAtuinUser() resource.Resource {
 // AtuinUser defines the resource implementation.
 type AtuinUser struct {
 	client *atuin.AtuinClient
+	naming atuinNaming
 }
 
 // AtuinUserModel describes the resource data model.
 type AtuinUserModel struct {
-	Username  types.String `tfsdk:"username"`
-	Password  types.String `tfsdk:"password"`
-	Email     types.String `tfsdk:"email"`
-	Base64Key types.String `tfsdk:"base64_key"`
-	Bip39Key  types.String `tfsdk:"bip39_key"`
+	Username            types.String   `tfsdk:"username"`
+	FullUsername        types.String   `tfsdk:"full_username"`
+	Password            types.String   `tfsdk:"password"`
+	PasswordGeneration  types.Object   `tfsdk:"password_generation"`
+	PasswordKeepers     types.Map      `tfsdk:"password_keepers"`
+	PasswordWO          types.String   `tfsdk:"password_wo"`
+	PasswordWOVersion   types.Int64    `tfsdk:"password_wo_version"`
+	MinPasswordStrength types.Int64    `tfsdk:"min_password_strength"`
+	Email               types.String   `tfsdk:"email"`
+	EncryptionKey       types.String   `tfsdk:"encryption_key"`
+	Base64Key           types.String   `tfsdk:"base64_key"`
+	Bip39Key            types.String   `tfsdk:"bip39_key"`
+	KeyVersion          types.Int64    `tfsdk:"key_version"`
+	OnPasswordDrift     types.String   `tfsdk:"on_password_drift"`
+	EmailChange         types.String   `tfsdk:"email_change"`
+	Timeouts            timeouts.Value `tfsdk:"timeouts"`
 }
 
 func (r *AtuinUser) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -49,27 +95,102 @@ func (r *AtuinUser) Schema(ctx context.Context, req resource.SchemaRequest, resp
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Atuin user",
+		Version:             atuinUserSchemaVersion,
 
 		Attributes: map[string]schema.Attribute{
 			"username": schema.StringAttribute{
-				MarkdownDescription: "Username of Atuin user",
+				MarkdownDescription: "Username of Atuin user, of up to 32 alphanumeric characters, dashes and underscores. The `username_prefix` of the provider is added to it, unless it already starts with the prefix.",
 				Required:            true,
-				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, atuin.MaxUsernameLength),
+					stringvalidator.RegexMatches(usernameRegexp, "must only contain alphanumeric characters, dashes and underscores"),
+				},
+			},
+			"full_username": schema.StringAttribute{
+				MarkdownDescription: "Username of the Atuin account, with the `username_prefix` of the provider. Changing it replaces the user.",
+				Computed:            true,
 			},
 			"password": schema.StringAttribute{
-				MarkdownDescription: "Password of Atuin user",
-				Required:            true,
-				Sensitive:           true,
+				MarkdownDescription: "Password of Atuin user. Only one of `password` or `password_wo` can be set. Without either, a password is generated as configured in `password_generation`, " +
+					"which is kept until `password_keepers` changes.",
+				Optional:   true,
+				Computed:   true,
+				Sensitive:  true,
+				Validators: []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"password_generation": schema.SingleNestedAttribute{
+				MarkdownDescription: "How the password is generated when neither `password` nor `password_wo` is set. Changing it does not generate a new password, change `password_keepers` for that.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"length": schema.Int64Attribute{
+						MarkdownDescription: fmt.Sprintf("Length of the password. Defaults to %d.", defaultPasswordLength),
+						Optional:            true,
+						Validators:          []validator.Int64{int64validator.AtLeast(8)},
+					},
+					"lower": schema.BoolAttribute{
+						MarkdownDescription: "Include lowercase letters. Defaults to `true`.",
+						Optional:            true,
+					},
+					"upper": schema.BoolAttribute{
+						MarkdownDescription: "Include uppercase letters. Defaults to `true`.",
+						Optional:            true,
+					},
+					"numeric": schema.BoolAttribute{
+						MarkdownDescription: "Include digits. Defaults to `true`.",
+						Optional:            true,
+					},
+					"special": schema.BoolAttribute{
+						MarkdownDescription: fmt.Sprintf("Include the special characters `%s`. Defaults to `true`.", passwordSpecialCharacters),
+						Optional:            true,
+					},
+				},
+			},
+			"password_keepers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary map of values that, when changed, generates a new password and changes the password of the account to it. " +
+					"Only applies when neither `password` nor `password_wo` is set. Adding it to an existing user does not generate a new password.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"password_wo": schema.StringAttribute{
+				MarkdownDescription: "Password of Atuin user, which is never stored in the plan or state. Requires Terraform 1.11 or later, and `password_wo_version`. " +
+					"As Terraform does not know the password, the user can only be deleted with the session of the provider `auth` block, when it is the same user.",
+				Optional:   true,
+				Sensitive:  true,
+				WriteOnly:  true,
+				Validators: []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"password_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "Version of `password_wo`. Change it when `password_wo` changes. A new version does not change the password of the account, as the Atuin server needs the current password to change it, which Terraform does not know: " +
+					"change the password with the Atuin CLI first, and the plan fails when `password_wo` is not the current password. Only switching from `password` to `password_wo` changes the password, to `password_wo`.",
+				Optional: true,
+			},
+			"min_password_strength": schema.Int64Attribute{
+				MarkdownDescription: "Minimum strength of `password` or `password_wo`, from 0 for a password that is guessed in seconds to 4 for one that cannot be guessed in years, as estimated by [zxcvbn](https://github.com/dropbox/zxcvbn). " +
+					"Passwords based on the username or email are weaker. Without it, any non-empty password is accepted.",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.Between(0, 4)},
 			},
 			"email": schema.StringAttribute{
-				MarkdownDescription: "Email of Atuin user",
-				Required:            true,
+				MarkdownDescription: "Email of Atuin user. Defaults to the `email_template` of the provider.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					validEmail(),
+					stringvalidator.LengthAtMost(atuin.MaxEmailLength),
+				},
+			},
+			"encryption_key": schema.StringAttribute{
+				MarkdownDescription: "Encryption key of Atuin user, e.g. of an existing Atuin installation, as a BIP39 mnemonic (`atuin key`), base64 encoded, or as the contents of the Atuin key file. " +
+					"Defaults to a generated key. Changing it re-encrypts all records of the Atuin user on the server with the new key.",
+				Optional:  true,
+				Sensitive: true,
 			},
 			"base64_key": schema.StringAttribute{
 				Computed:  true,
 				Sensitive: true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					unknownIfChanged(path.Root("key_version")),
 				},
 			},
 			"bip39_key": schema.StringAttribute{
@@ -77,8 +198,33 @@ func (r *AtuinUser) Schema(ctx context.Context, req resource.SchemaRequest, resp
 				Sensitive: true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					unknownIfChanged(path.Root("key_version")),
 				},
 			},
+			"key_version": schema.Int64Attribute{
+				MarkdownDescription: "Version of the encryption key, which triggers a key rotation. Changing it, e.g. by incrementing it, generates a new encryption key, re-encrypts all records of the Atuin user on the server with it, and updates `base64_key` and `bip39_key`. " +
+					"The user is not replaced, so its history is kept. Has no effect when `encryption_key` is set. Defaults to `0`.",
+				Optional: true,
+				Computed: true,
+				Default:  int64default.StaticInt64(0),
+			},
+			"on_password_drift": schema.StringAttribute{
+				MarkdownDescription: "What to do when the password of the account was changed outside Terraform: `error` fails the refresh, `warn` plans a password change and warns about it, and `ignore` plans a password change silently. Defaults to `warn`. " +
+					"The planned change succeeds once `password` is set to the current password of the account.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(passwordDriftWarn),
+			},
+			"email_change": schema.StringAttribute{
+				MarkdownDescription: "What to do when the email changes. The provider changes the email on the server when the server supports it, which no Atuin server release does so far. Otherwise, " +
+					"`requires_replace` replaces the user, which deletes its history on the server, `warn` only updates the email in state and warns about it, and `ignore` only updates the email in state. Defaults to `warn`.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(emailChangeWarn),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
 		},
 	}
 }
@@ -89,21 +235,337 @@ func (r *AtuinUser) Configure(ctx context.Context, req resource.ConfigureRequest
 		return
 	}
 
-	client, ok := req.ProviderData.(*atuin.AtuinClient)
+	data, ok := req.ProviderData.(*atuinResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *atuinResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+	r.naming = data.naming
+}
+
+func (r *AtuinUser) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var password, passwordWO types.String
+	var passwordWOVersion types.Int64
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("password"), &password)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("password_wo"), &passwordWO)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("password_wo_version"), &passwordWOVersion)...)
+
+	if !password.IsNull() && !passwordWO.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Invalid Password Configuration",
+			"Only one of password or password_wo can be set.",
+		)
+	}
+
+	resp.Diagnostics.Append(validatePasswordGeneration(ctx, req.Config, !password.IsNull() || !passwordWO.IsNull())...)
+
+	if !passwordWO.IsNull() && passwordWOVersion.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password_wo_version"),
+			"Missing Password Version",
+			"The password_wo_version must be set with password_wo, as Terraform cannot detect changes of a write-only password.",
+		)
+	}
+
+	if passwordWO.IsNull() && !passwordWOVersion.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password_wo_version"),
+			"Invalid Password Version",
+			"The password_wo_version can only be set with password_wo.",
+		)
+	}
+
+	resp.Diagnostics.Append(validatePasswordStrength(ctx, req.Config, password, passwordWO)...)
+
+	var encryptionKey types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("encryption_key"), &encryptionKey)...)
+
+	if !encryptionKey.IsNull() && !encryptionKey.IsUnknown() {
+		if _, err := atuin.ParseEncryptionKey(encryptionKey.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("encryption_key"),
+				"Invalid Encryption Key",
+				fmt.Sprintf("The encryption key must be a 32 byte key, as a BIP39 mnemonic, base64 encoded, or as the contents of the Atuin key file: %s", err),
+			)
+		}
+	}
+
+	for _, v := range []struct {
+		attribute string
+		summary   string
+		allowed   []string
+	}{
+		{"on_password_drift", "Invalid Password Drift Behaviour", passwordDriftBehaviours},
+		{"email_change", "Invalid Email Change Policy", emailChangePolicies},
+	} {
+		var value types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(v.attribute), &value)...)
+
+		if value.IsNull() || value.IsUnknown() {
+			continue
+		}
+
+		if !slices.Contains(v.allowed, value.ValueString()) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(v.attribute),
+				v.summary,
+				fmt.Sprintf("The %s must be one of %s, got: %q.", v.attribute, strings.Join(v.allowed, ", "), value.ValueString()),
+			)
+		}
+	}
+}
+
+// validatePasswordGeneration checks that the password generation settings only come without a configured
+// password, and enable a character class.
+func validatePasswordGeneration(ctx context.Context, config tfsdk.Config, passwordConfigured bool) diag.Diagnostics {
+	var generationObject types.Object
+	var keepers types.Map
+	diags := config.GetAttribute(ctx, path.Root("password_generation"), &generationObject)
+	diags.Append(config.GetAttribute(ctx, path.Root("password_keepers"), &keepers)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	for _, v := range []struct {
+		attribute string
+		set       bool
+	}{
+		{"password_generation", !generationObject.IsNull()},
+		{"password_keepers", !keepers.IsNull()},
+	} {
+		if passwordConfigured && v.set {
+			diags.AddAttributeError(
+				path.Root(v.attribute),
+				"Invalid Password Configuration",
+				fmt.Sprintf("The %s can only be set when neither password nor password_wo is set.", v.attribute),
+			)
+		}
+	}
+
+	generation, generationDiags := passwordGeneration(ctx, generationObject)
+	diags.Append(generationDiags...)
+	if generation == nil {
+		return diags
+	}
+
+	disabled := 0
+	for _, enabled := range []types.Bool{generation.Lower, generation.Upper, generation.Numeric, generation.Special} {
+		if !enabled.IsNull() && !enabled.IsUnknown() && !enabled.ValueBool() {
+			disabled++
+		}
+	}
+
+	if disabled == 4 {
+		diags.AddAttributeError(
+			path.Root("password_generation"),
+			"Invalid Password Generation",
+			"At least one of lower, upper, numeric or special must be enabled.",
+		)
+	}
+
+	return diags
+}
+
+// validatePasswordStrength checks the configured password against the minimum password strength.
+func validatePasswordStrength(ctx context.Context, config tfsdk.Config, password, passwordWO types.String) diag.Diagnostics {
+	var minStrength types.Int64
+	var username, email types.String
+	diags := config.GetAttribute(ctx, path.Root("min_password_strength"), &minStrength)
+	diags.Append(config.GetAttribute(ctx, path.Root("username"), &username)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("email"), &email)...)
+
+	attribute, value := path.Root("password"), password
+	if password.IsNull() {
+		attribute, value = path.Root("password_wo"), passwordWO
+	}
+
+	if diags.HasError() || minStrength.IsNull() || minStrength.IsUnknown() || value.IsNull() || value.IsUnknown() {
+		return diags
+	}
+
+	if strength := passwordStrength(value.ValueString(), username.ValueString(), email.ValueString()); strength < minStrength.ValueInt64() {
+		diags.AddAttributeError(
+			attribute,
+			"Weak Password",
+			fmt.Sprintf("The password has a strength of %d, below the min_password_strength of %d. Use a longer password, which is not based on words, dates or the username.", strength, minStrength.ValueInt64()),
+		)
+	}
+
+	return diags
+}
+
+// ModifyPlan applies the naming conventions of the provider: it plans the full username and the email
+// from the template, and replaces the user when its full username changes.
+func (r *AtuinUser) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the user is destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, config AtuinUserModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A write-only password is never stored in state
+	if !config.PasswordWO.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("password"), types.StringNull())...)
+	}
+
+	// The full username is not known until apply, and may change, so an existing user is replaced
+	if plan.Username.IsUnknown() {
+		if !req.State.Raw.IsNull() {
+			resp.RequiresReplace.Append(path.Root("username"))
+		}
+		return
+	}
+
+	fullUsername := r.naming.fullUsername(plan.Username.ValueString())
+	if err := atuin.ValidateUsername(fullUsername); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("username"), "Invalid Username", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("full_username"), fullUsername)...)
+
+	if config.Email.IsNull() {
+		email, ok := r.naming.email(fullUsername)
+		if !ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("email"),
+				"Missing Email",
+				"Set the email of the Atuin user, or the email_template of the provider.",
+			)
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("email"), email)...)
+	}
+
+	// The computed keys mirror a configured encryption key
+	if !config.EncryptionKey.IsNull() && !config.EncryptionKey.IsUnknown() {
+		key, bip39Key, err := encryptionKeys(config.EncryptionKey.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("encryption_key"), "Invalid Encryption Key", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("base64_key"), key)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("bip39_key"), bip39Key)...)
+	}
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var state AtuinUserModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Users created before the full username was tracked have the username of the account as username
+	if stateFullUsername(&state) != fullUsername {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("full_username"))
+	}
+
+	r.planEmailChange(ctx, &state, resp)
+	r.planWriteOnlyPasswordVersion(ctx, &state, &config, fullUsername, resp)
+
+	if config.Password.IsNull() && config.PasswordWO.IsNull() {
+		planGeneratedPassword(ctx, &state, &plan, resp)
+	}
+}
+
+// planWriteOnlyPasswordVersion rejects a new password_wo_version of a user that already has a write-only
+// password, unless password_wo is the current password of the account. A new version cannot change the
+// password, as the Atuin server needs the current password for that, which Terraform does not know.
+func (r *AtuinUser) planWriteOnlyPasswordVersion(ctx context.Context, state, config *AtuinUserModel, fullUsername string, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || !state.Password.IsNull() || state.PasswordWOVersion.IsNull() || stateFullUsername(state) != fullUsername {
+		return
+	}
+	if config.PasswordWO.IsNull() || config.PasswordWO.IsUnknown() || config.PasswordWOVersion.IsUnknown() || config.PasswordWOVersion.Equal(state.PasswordWOVersion) {
+		return
+	}
+
+	_, err := r.client.Login(ctx, fullUsername, config.PasswordWO.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password_wo"),
+			"Unable to Change Atuin Password",
+			fmt.Sprintf("Unable to login user with the configured password_wo: %s. A new password_wo_version does not change the password of the account, as the Atuin server needs the current password to change it, which Terraform does not know. "+
+				"Change the password with the Atuin CLI first, and then update password_wo and password_wo_version.", err),
 		)
+	}
+}
 
+// planGeneratedPassword keeps the password in state when no password is configured, until the password
+// keepers change. Like unknownIfChanged, keepers added to an existing user do not count as a change.
+// Without a password in state, as it drifted or was write-only, no password can be generated, as the
+// Atuin server needs the current password to change it.
+func planGeneratedPassword(ctx context.Context, state, plan *AtuinUserModel, resp *resource.ModifyPlanResponse) {
+	if state.Password.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Unable to Generate Atuin Password",
+			"Terraform does not know the current password of the account, as it was changed outside Terraform or is write-only, and the Atuin server needs it to change the password. "+
+				"Set password to the current password of the account and apply, after which password can be removed from the configuration again to keep it.",
+		)
 		return
 	}
 
-	r.client = client
+	password := state.Password
+	if !state.PasswordKeepers.IsNull() && !plan.PasswordKeepers.Equal(state.PasswordKeepers) {
+		password = types.StringUnknown()
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("password"), password)...)
+}
+
+// planEmailChange applies the email change policy, once the planned email is known, including an email
+// from the template of the provider.
+func (r *AtuinUser) planEmailChange(ctx context.Context, state *AtuinUserModel, resp *resource.ModifyPlanResponse) {
+	var email, policy types.String
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("email"), &email)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("email_change"), &policy)...)
+
+	// An imported user has no email in state, as the server does not report it
+	if resp.Diagnostics.HasError() || email.IsUnknown() || state.Email.IsNull() || email.Equal(state.Email) {
+		return
+	}
+
+	switch policy.ValueString() {
+	case emailChangeRequiresReplace:
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("email"))
+	case emailChangeWarn:
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("email"),
+			"Email Change May Not Reach the Atuin Server",
+			"The provider changes the email on the Atuin server if the server supports it. No Atuin server release does so far, in which case only the email in state changes. "+
+				"Set email_change to requires_replace to replace the user instead, or to ignore to silence this warning.",
+		)
+	}
+}
+
+// stateFullUsername returns the full username of a user in state, which was not tracked by older versions of the provider.
+func stateFullUsername(data *AtuinUserModel) string {
+	if data.FullUsername.IsNull() || data.FullUsername.IsUnknown() {
+		return data.Username.ValueString()
+	}
+	return data.FullUsername.ValueString()
 }
 
 func (r *AtuinUser) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := startSpan(ctx, r.client, "atuin_user.Create")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
 	var data *AtuinUserModel
 
 	// Read Terraform plan data into the model
@@ -113,25 +575,45 @@ func (r *AtuinUser) Create(ctx context.Context, req resource.CreateRequest, resp
 		return
 	}
 
-	tflog.Info(ctx, data.Username.String())
+	createTimeout, diags := data.Timeouts.Create(ctx, atuinUserDefaultTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	password, diags := configuredPassword(ctx, data, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	_, err := r.client.CreateUser(data.Username.ValueString(), data.Password.ValueString(), data.Email.String())
+	tflog.Info(ctx, data.FullUsername.String())
+
+	_, err := r.client.CreateUser(ctx, data.FullUsername.ValueString(), password, data.Email.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create Atuin user, got error: %s", err))
 		return
 	}
 
-	// Generate encryption key and add to state
-	key, err := atuin.GenerateEncryptionKey()
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create encryption key, got error: %s", err))
+	// Use the configured encryption key, or generate one, and add to state
+	var key string
+	if data.EncryptionKey.IsNull() {
+		key, err = atuin.GenerateEncryptionKey()
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create encryption key, got error: %s", err))
+		}
+	} else {
+		key = data.EncryptionKey.ValueString()
 	}
-	data.Base64Key = types.StringValue(key)
 
-	bip39Key, err := atuin.ConvertEncryptionKeyToBip39(data.Base64Key.ValueString())
+	key, bip39Key, err := encryptionKeys(key)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to convert encryption key to bip39, got error: %s", err))
 	}
+	data.Base64Key = types.StringValue(key)
 	data.Bip39Key = types.StringValue(bip39Key)
 
 	// Write logs using the tflog package
@@ -143,14 +625,60 @@ func (r *AtuinUser) Create(ctx context.Context, req resource.CreateRequest, resp
 }
 
 func (r *AtuinUser) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := startSpan(ctx, r.client, "atuin_user.Read")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
 	var data *AtuinUserModel
 
 	// Read Terraform prior state data into the model
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
 
-	_, err := r.client.Login(data.Username.ValueString(), data.Password.ValueString())
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, atuinUserDefaultTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	data.FullUsername = types.StringValue(stateFullUsername(data))
+
+	// Without a password in state, a user with a write-only password can only be looked up
+	if data.Password.IsNull() && !data.PasswordWOVersion.IsNull() {
+		exists, err := r.client.UserExists(ctx, data.FullUsername.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to look up user: %s", err))
+			return
+		}
+
+		if !exists {
+			tflog.Warn(ctx, "Atuin user no longer exists, removing it from state", map[string]any{"username": data.FullUsername.ValueString()})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	_, err := r.client.Login(ctx, data.FullUsername.ValueString(), data.Password.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to login user: %s", err))
+		if r.userDeleted(ctx, data.FullUsername.ValueString(), err) {
+			tflog.Warn(ctx, "Atuin user no longer exists, removing it from state", map[string]any{"username": data.FullUsername.ValueString()})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		if atuin.IsUnauthorized(err) && data.OnPasswordDrift.ValueString() != passwordDriftError {
+			r.passwordDrifted(ctx, data, resp)
+		} else {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to login user: %s", err))
+		}
 	}
 
 	if resp.Diagnostics.HasError() {
@@ -161,7 +689,45 @@ func (r *AtuinUser) Read(ctx context.Context, req resource.ReadRequest, resp *re
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// passwordDrifted clears the password in state when the account rejects it, so that the next plan shows
+// a password change. Older states without a drift behaviour warn, like the default.
+func (r *AtuinUser) passwordDrifted(ctx context.Context, data *AtuinUserModel, resp *resource.ReadResponse) {
+	tflog.Warn(ctx, "Password of Atuin user was changed outside Terraform", map[string]any{"username": data.FullUsername.ValueString()})
+
+	data.Password = types.StringNull()
+
+	if data.OnPasswordDrift.ValueString() == passwordDriftIgnore {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeWarning(
+		path.Root("password"),
+		"Atuin Password Changed Outside Terraform",
+		fmt.Sprintf("The Atuin server rejected the password of user %q, so it was changed outside Terraform, e.g. with the Atuin CLI. "+
+			"Terraform plans to update the password, which only succeeds once the password in the configuration is the current password of the account. "+
+			"Set it to the current password, or change the password of the account back to the configured one. "+
+			"For a generated password, set password to the current password until the next apply.", data.FullUsername.ValueString()),
+	)
+}
+
+// userDeleted reports whether a failed login is caused by the user not existing anymore. Only a lookup
+// of the user can tell: a 404 Not Found from the login may also come from a proxy or a wrong host.
+func (r *AtuinUser) userDeleted(ctx context.Context, username string, loginErr error) bool {
+	tflog.Debug(ctx, "Looking up Atuin user after failed login", map[string]any{"error": loginErr.Error()})
+
+	exists, err := r.client.UserExists(ctx, username)
+	if err != nil {
+		tflog.Debug(ctx, "Unable to look up Atuin user", map[string]any{"error": err.Error()})
+		return false
+	}
+
+	return !exists
+}
+
 func (r *AtuinUser) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, span := startSpan(ctx, r.client, "atuin_user.Update")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
 	var data *AtuinUserModel
 
 	// Read Terraform plan data into the model
@@ -171,27 +737,185 @@ func (r *AtuinUser) Update(ctx context.Context, req resource.UpdateRequest, resp
 		return
 	}
 
+	updateTimeout, diags := data.Timeouts.Update(ctx, atuinUserDefaultTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
 	var oldData *AtuinUserModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &oldData)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if data.Password.ValueString() != oldData.Password.ValueString() {
-		err := r.client.UpdatePassword(data.Username.ValueString(), oldData.Password.ValueString(), data.Password.ValueString())
+	password, diags := configuredPassword(ctx, data, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	writeOnly := data.Password.IsNull()
+
+	switch {
+	case !oldData.Password.IsNull():
+		if password != oldData.Password.ValueString() {
+			err := r.client.UpdatePassword(ctx, data.FullUsername.ValueString(), oldData.Password.ValueString(), password)
+			if err != nil {
+				resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update password, got error: %s", err))
+				return
+			}
+		}
+	case !writeOnly || !data.PasswordWOVersion.Equal(oldData.PasswordWOVersion):
+		// The password drifted, or is no longer in state: it can only be updated with the current password
+		// of the account, which Terraform does not know, so the configured password must be the current one
+		_, err := r.client.Login(ctx, data.FullUsername.ValueString(), password)
 		if err != nil {
-			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update password, got error: %s", err))
+			attribute := path.Root("password")
+			if writeOnly {
+				attribute = path.Root("password_wo")
+			}
+
+			resp.Diagnostics.AddAttributeError(
+				attribute,
+				"Unable to Change Atuin Password",
+				fmt.Sprintf("Unable to login user with the configured password: %s. The Atuin server needs the current password to change it, which Terraform does not know. "+
+					"Set the password to the current password of the account, or change it with the Atuin CLI first.", err),
+			)
+			return
 		}
 	}
 
 	data.Base64Key = oldData.Base64Key
 	data.Bip39Key = oldData.Bip39Key
 
-	// Save updated data into Terraform state. It seems email is not really used yet, so we don't need to do any calls to update it.
+	if !data.Email.Equal(oldData.Email) {
+		r.updateEmail(ctx, data, password, resp)
+		if resp.Diagnostics.HasError() {
+			// The password may already be changed, but neither the email nor the key
+			data.Email = oldData.Email
+			data.KeyVersion = oldData.KeyVersion
+			data.EncryptionKey = oldData.EncryptionKey
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			return
+		}
+	}
+
+	// The key is only rotated after a successful password update, as it needs to login with the new password.
+	// A configured encryption key takes precedence over a new key version.
+	var newKey string
+	var err error
+
+	switch {
+	case !data.EncryptionKey.IsNull():
+		newKey, err = atuin.ParseEncryptionKey(data.EncryptionKey.ValueString())
+	case keyRotationTriggered(data, oldData):
+		newKey, err = atuin.GenerateEncryptionKey()
+	}
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create encryption key, got error: %s", err))
+		return
+	}
+
+	if newKey != "" && newKey != oldData.Base64Key.ValueString() {
+		r.rotateKey(ctx, data, password, newKey, resp)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// keyRotationTriggered reports whether key_version changed, which is the trigger to rotate the encryption key.
+// Like unknownIfChanged, a key_version added to a user in state from before it existed does not count as a change.
+func keyRotationTriggered(data, oldData *AtuinUserModel) bool {
+	return !oldData.KeyVersion.IsNull() && !data.KeyVersion.Equal(oldData.KeyVersion)
+}
+
+// updateEmail changes the email on the server. When the server cannot change it, the email is only
+// changed in state, as planned with the email change policy.
+func (r *AtuinUser) updateEmail(ctx context.Context, data *AtuinUserModel, password string, resp *resource.UpdateResponse) {
+	sessionToken, err := r.client.Login(ctx, data.FullUsername.ValueString(), password)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to login user: %s", err))
+		return
+	}
+
+	err = r.client.UpdateEmail(ctx, sessionToken, data.Email.ValueString())
+	if errors.Is(err, errors.ErrUnsupported) {
+		tflog.Info(ctx, "Atuin server cannot change the email, only changing it in state")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update email, got error: %s", err))
+	}
+}
+
+// rotateKey re-encrypts all records of the user with the new encryption key. The records on the server are
+// encrypted with the new key as soon as the record store is deleted, so the new key is saved in state before,
+// and stays there when uploading the records fails halfway. Only when the record store could not be deleted
+// the prior state is kept, so that the rotation is planned again.
+func (r *AtuinUser) rotateKey(ctx context.Context, data *AtuinUserModel, password, newKey string, resp *resource.UpdateResponse) {
+	bip39Key, err := atuin.ConvertEncryptionKeyToBip39(newKey)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to convert encryption key to bip39, got error: %s", err))
+		return
+	}
+
+	sessionToken, err := r.client.Login(ctx, data.FullUsername.ValueString(), password)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to login user: %s", err))
+		return
+	}
+
+	records, err := r.client.ReencryptRecords(ctx, sessionToken, data.Base64Key.ValueString(), newKey)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to re-encrypt records with new encryption key, got error: %s", err))
+		return
+	}
+
+	priorState := resp.State.Raw.Copy()
+
+	data.Base64Key = types.StringValue(newKey)
+	data.Bip39Key = types.StringValue(bip39Key)
+
+	if len(records) > 0 {
+		resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		err = r.client.ReplaceStore(ctx, sessionToken, records)
+
+		var partial *atuin.PartialReplaceError
+		if errors.As(err, &partial) {
+			resp.Diagnostics.AddError(
+				"Atuin Records Lost",
+				fmt.Sprintf("Unable to upload the re-encrypted records: %s. The new encryption key is saved in state, and encrypts the %d records that were uploaded. "+
+					"The lost records can only be restored from a machine that still has them, after switching it to the new key.", err, partial.Uploaded),
+			)
+			return
+		}
+		if err != nil {
+			resp.State.Raw = priorState
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to replace records with re-encrypted records, got error: %s", err))
+			return
+		}
+	}
+
+	tflog.Trace(ctx, "rotated encryption key of Atuin user")
+}
+
 func (r *AtuinUser) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := startSpan(ctx, r.client, "atuin_user.Delete")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
 	var data *AtuinUserModel
 
 	// Read Terraform prior state data into the model
@@ -201,14 +925,99 @@ func (r *AtuinUser) Delete(ctx context.Context, req resource.DeleteRequest, resp
 		return
 	}
 
-	err := r.client.DeleteUser(data.Username.ValueString(), data.Password.ValueString())
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, atuinUserDefaultTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if !data.Password.IsNull() {
+		err := r.client.DeleteUser(ctx, stateFullUsername(data), data.Password.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete Atuin user, got error: %s", err))
+		}
+		return
+	}
+
+	sessionToken, diags := r.deleteSession(ctx, stateFullUsername(data))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteAccount(ctx, sessionToken)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete Atuin user, got error: %s", err))
-		return
 	}
 }
 
+// deleteSession returns a session to delete a user without a password in state, which is the session of
+// the provider if it belongs to the same user.
+func (r *AtuinUser) deleteSession(ctx context.Context, username string) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if providerSession := r.client.SessionToken(); providerSession != "" {
+		me, err := r.client.Me(ctx, providerSession)
+		if err == nil && me == username {
+			return providerSession, diags
+		}
+	}
+
+	diags.AddError(
+		"Missing Atuin Session",
+		fmt.Sprintf("Unable to delete Atuin user %q, as there is no password in state and no session of the user. "+
+			"Configure the provider auth block with the credentials of the user, or delete the user with the Atuin CLI and remove it from state.", username),
+	)
+	return "", diags
+}
+
+// encryptionKeys parses an encryption key, and returns it base64 encoded and as a BIP39 mnemonic.
+func encryptionKeys(key string) (string, string, error) {
+	b64Key, err := atuin.ParseEncryptionKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	bip39Key, err := atuin.ConvertEncryptionKeyToBip39(b64Key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return b64Key, bip39Key, nil
+}
+
+// configuredPassword returns the password, or the write-only password, which is only available in the configuration.
+// A password that is planned to be generated is generated, and set in the model.
+func configuredPassword(ctx context.Context, data *AtuinUserModel, config tfsdk.Config) (string, diag.Diagnostics) {
+	if data.Password.IsUnknown() {
+		generation, diags := passwordGeneration(ctx, data.PasswordGeneration)
+		if diags.HasError() {
+			return "", diags
+		}
+
+		password, err := generatePassword(generation)
+		if err != nil {
+			return "", diag.Diagnostics{diag.NewAttributeErrorDiagnostic(path.Root("password_generation"), "Unable to Generate Password", err.Error())}
+		}
+		data.Password = types.StringValue(password)
+	}
+
+	if !data.Password.IsNull() {
+		return data.Password.ValueString(), nil
+	}
+
+	var passwordWO types.String
+	diags := config.GetAttribute(ctx, path.Root("password_wo"), &passwordWO)
+	return passwordWO.ValueString(), diags
+}
+
 func (r *AtuinUser) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx, span := startSpan(ctx, r.client, "atuin_user.ImportState")
+	defer func() { endSpan(span, resp.Diagnostics) }()
+
 	idParts := strings.Split(req.ID, ",")
 
 	if len(idParts) != 3 || idParts[0] == "" || idParts[1] == "" || idParts[2] == "" {
@@ -232,8 +1041,13 @@ func (r *AtuinUser) ImportState(ctx context.Context, req resource.ImportStateReq
 		bip39Key, _ = atuin.ConvertEncryptionKeyToBip39(b64Key)
 	}
 
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("username"), idParts[0])...)
+	// The import identifier has the full username of the account, while the configuration may leave out the prefix
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("username"), strings.TrimPrefix(idParts[0], r.naming.usernamePrefix))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("full_username"), idParts[0])...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("password"), idParts[1])...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("base64_key"), b64Key)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("bip39_key"), bip39Key)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("key_version"), 0)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on_password_drift"), passwordDriftWarn)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("email_change"), emailChangeWarn)...)
 }
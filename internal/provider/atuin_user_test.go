@@ -1,10 +1,27 @@
 package provider
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAccExampleAtuinUserResource(t *testing.T) {
@@ -27,7 +44,7 @@ func TestAccExampleAtuinUserResource(t *testing.T) {
 			},
 			// Update and Read testing
 			{
-				Config: testAccExampleAtuinUserResourceConfig("twoflower", "pa$$word2", "test1234$yahoo.com"),
+				Config: testAccExampleAtuinUserResourceConfig("twoflower", "pa$$word2", "twoflower@yahoo.com"),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("atuin_user.test", "password", "pa$$word2"),
 				),
@@ -46,3 +63,887 @@ resource "atuin_user" "test" {
 }
 `, username, password, email)
 }
+
+func TestAccAtuinUserKeyRotation(t *testing.T) {
+	var firstKey string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAtuinUserKeyVersionConfig("nobby", "pa$$word", 0),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atuin_user.test", "key_version", "0"),
+					resource.TestCheckResourceAttrWith("atuin_user.test", "base64_key", func(value string) error {
+						firstKey = value
+						return nil
+					}),
+				),
+			},
+			{
+				Config: testAccAtuinUserKeyVersionConfig("nobby", "pa$$word", 1),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atuin_user.test", "key_version", "1"),
+					resource.TestCheckResourceAttrWith("atuin_user.test", "base64_key", func(value string) error {
+						if value == firstKey {
+							return fmt.Errorf("expected encryption key to be rotated")
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func testAccAtuinUserKeyVersionConfig(username, password string, keyVersion int) string {
+	return fmt.Sprintf(`
+resource "atuin_user" "test" {
+  username    = %[1]q
+  password    = %[2]q
+  email       = "%[1]s@example.com"
+  key_version = %[3]d
+}
+`, username, password, keyVersion)
+}
+
+func TestKeyRotationTriggered(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new types.Int64
+		expected bool
+	}{
+		{"unchanged", types.Int64Value(1), types.Int64Value(1), false},
+		{"bumped", types.Int64Value(1), types.Int64Value(2), true},
+		{"lowered", types.Int64Value(2), types.Int64Value(1), true},
+		// Users created before key_version existed keep their key
+		{"added", types.Int64Null(), types.Int64Value(0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, keyRotationTriggered(&AtuinUserModel{KeyVersion: tt.new}, &AtuinUserModel{KeyVersion: tt.old}))
+		})
+	}
+}
+
+func testAtuinUserUpdate(t *testing.T, r *AtuinUser, state, plan AtuinUserModel) *fwresource.UpdateResponse {
+	t.Helper()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	tfState := tfsdk.State{Schema: schemaResp.Schema}
+	if diags := tfState.Set(t.Context(), &state); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	tfPlan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := tfPlan.Set(t.Context(), &plan); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	tfConfig := tfsdk.Config{Schema: schemaResp.Schema, Raw: tfPlan.Raw}
+
+	// Like Terraform, the state is the prior state until Update sets it
+	resp := &fwresource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tfState.Raw.Copy()}}
+	r.Update(t.Context(), fwresource.UpdateRequest{State: tfState, Plan: tfPlan, Config: tfConfig}, resp)
+	return resp
+}
+
+func TestAtuinUserRotateKeyFailure(t *testing.T) {
+	oldKey, err := atuin.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodedOldKey, _ := atuin.DecodeEncryptionKey(oldKey)
+
+	record := atuin.Record{ID: "0190b6e2-5b8a-7c3e-9a1d-0f0e5c6f8a1b", Host: atuin.Host{ID: "octavo"}, Version: "v0", Tag: "history"}
+	if err := atuin.EncryptRecord(&record, []byte("ankh-morpork"), decodedOldKey); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		password     string
+		deleteStatus int
+		expectNewKey bool
+	}{
+		// The records on the server are encrypted with the new key, which must not be lost
+		{"upload fails", "swordfish", http.StatusOK, true},
+		// Nothing changed on the server, so the rotation is planned again
+		{"delete fails", "swordfish", http.StatusInternalServerError, false},
+		// The key is not rotated, and neither the password nor the key version may end up in state
+		{"password update fails", "octarine", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"session": "token"}`))
+			})
+			mux.HandleFunc("PATCH /account/password", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			mux.HandleFunc("GET /api/v0/record", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"hosts": {"octavo": {"history": 0}}}`))
+			})
+			mux.HandleFunc("GET /api/v0/record/next", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode([]atuin.Record{record})
+			})
+			mux.HandleFunc("DELETE /api/v0/store", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.deleteStatus)
+			})
+			mux.HandleFunc("POST /api/v0/record", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+
+			state := testAtuinUserState(t)
+			state.Base64Key = types.StringValue(oldKey)
+
+			plan := state
+			plan.Password = types.StringValue(tt.password)
+			plan.KeyVersion = types.Int64Value(1)
+			plan.Base64Key = types.StringUnknown()
+			plan.Bip39Key = types.StringUnknown()
+
+			resp := testAtuinUserUpdate(t, r, state, plan)
+			assert.True(t, resp.Diagnostics.HasError())
+
+			var data AtuinUserModel
+			resp.State.Get(t.Context(), &data)
+			if tt.expectNewKey {
+				assert.NotEqual(t, oldKey, data.Base64Key.ValueString())
+				assert.False(t, data.Bip39Key.IsUnknown())
+				assert.Equal(t, plan.KeyVersion, data.KeyVersion)
+				assert.Contains(t, resp.Diagnostics.Errors()[0].Detail(), "records 1 to 1 of 1")
+			} else {
+				assert.Equal(t, state, data)
+			}
+		})
+	}
+}
+
+func TestAccAtuinUserNamingConventions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAtuinUserNamingConfig("vimes"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atuin_user.test", "username", "vimes"),
+					resource.TestCheckResourceAttr("atuin_user.test", "full_username", "watch-vimes"),
+					resource.TestCheckResourceAttr("atuin_user.test", "email", "watch-vimes@atuin.example.com"),
+				),
+			},
+			// A username that already has the prefix is the same user
+			{
+				Config:   testAccAtuinUserNamingConfig("watch-vimes"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func testAccAtuinUserNamingConfig(username string) string {
+	return fmt.Sprintf(`
+provider "atuin" {
+  username_prefix = "watch-"
+  email_template  = "{{username}}@atuin.example.com"
+}
+
+resource "atuin_user" "test" {
+  username = %[1]q
+  password = "pa$$word"
+}
+`, username)
+}
+
+func TestAtuinUserModifyPlanUnknownUsername(t *testing.T) {
+	r := &AtuinUser{}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	state := testAtuinUserState(t)
+
+	plan := state
+	plan.Username = types.StringUnknown()
+	plan.FullUsername = types.StringUnknown()
+	tfPlan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := tfPlan.Set(t.Context(), &plan); diags.HasError() {
+		t.Fatal(diags)
+	}
+	tfConfig := tfsdk.Config{Schema: schemaResp.Schema, Raw: tfPlan.Raw}
+
+	// An existing user is replaced, as its full username may change
+	resp := testAtuinUserModifyPlan(t, r, state, plan)
+	assert.False(t, resp.Diagnostics.HasError())
+	assert.True(t, resp.RequiresReplace.Contains(path.Root("username")))
+
+	// A new user has nothing to replace
+	resp = &fwresource.ModifyPlanResponse{Plan: tfPlan}
+	r.ModifyPlan(t.Context(), fwresource.ModifyPlanRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(tfPlan.Raw.Type(), nil)}, Plan: tfPlan, Config: tfConfig}, resp)
+	assert.False(t, resp.Diagnostics.HasError())
+	assert.Empty(t, resp.RequiresReplace)
+}
+
+func TestAtuinUserDeleted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/twoflower":
+			_, _ = w.Write([]byte(`{"username": "twoflower"}`))
+		case "/user/vimes":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"reason": "user not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+
+	assert.True(t, r.userDeleted(t.Context(), "rincewind", &atuin.APIError{StatusCode: http.StatusNotFound, Reason: "user not found"}))
+	assert.True(t, r.userDeleted(t.Context(), "rincewind", errors.New("unexpected response")))
+	assert.False(t, r.userDeleted(t.Context(), "twoflower", &atuin.APIError{StatusCode: http.StatusUnauthorized, Reason: "password is not correct"}))
+	// A 404 Not Found from the login alone does not mean the user was deleted
+	assert.False(t, r.userDeleted(t.Context(), "twoflower", &atuin.APIError{StatusCode: http.StatusNotFound, Reason: "not found"}))
+	assert.False(t, r.userDeleted(t.Context(), "vimes", &atuin.APIError{StatusCode: http.StatusNotFound, Reason: "not found"}))
+}
+
+// testAtuinUserState returns the state of a user with a password, in which every attribute has a value
+// of its type. Tests override the attributes they are about.
+func testAtuinUserState(t *testing.T) AtuinUserModel {
+	t.Helper()
+
+	return AtuinUserModel{
+		Username:            types.StringValue("rincewind"),
+		FullUsername:        types.StringValue("rincewind"),
+		Password:            types.StringValue("swordfish"),
+		PasswordGeneration:  types.ObjectNull(passwordGenerationAttrTypes),
+		PasswordKeepers:     types.MapNull(types.StringType),
+		PasswordWO:          types.StringNull(),
+		PasswordWOVersion:   types.Int64Null(),
+		MinPasswordStrength: types.Int64Null(),
+		Email:               types.StringValue("rincewind@uu.am"),
+		EncryptionKey:       types.StringNull(),
+		Base64Key:           types.StringValue("key"),
+		Bip39Key:            types.StringValue("mnemonic"),
+		KeyVersion:          types.Int64Value(0),
+		OnPasswordDrift:     types.StringValue(passwordDriftWarn),
+		EmailChange:         types.StringValue(emailChangeWarn),
+		Timeouts:            nullAtuinUserTimeouts(t.Context()),
+	}
+}
+
+func testAtuinUserRead(t *testing.T, r *AtuinUser, data AtuinUserModel) *fwresource.ReadResponse {
+	t.Helper()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	if diags := state.Set(t.Context(), &data); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(t.Context(), fwresource.ReadRequest{State: state}, resp)
+	return resp
+}
+
+func TestAtuinUserReadPasswordDrift(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"reason": "password is not correct"}`))
+		default:
+			_, _ = w.Write([]byte(`{"username": "rincewind"}`))
+		}
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+	data := testAtuinUserState(t)
+
+	for behaviour, want := range map[string]struct{ errors, warnings int }{
+		passwordDriftError:  {errors: 1},
+		passwordDriftWarn:   {warnings: 1},
+		passwordDriftIgnore: {},
+	} {
+		data.OnPasswordDrift = types.StringValue(behaviour)
+		resp := testAtuinUserRead(t, r, data)
+
+		assert.Equal(t, want.errors, resp.Diagnostics.ErrorsCount(), behaviour)
+		assert.Equal(t, want.warnings, resp.Diagnostics.WarningsCount(), behaviour)
+
+		if want.errors == 0 {
+			var password types.String
+			resp.State.GetAttribute(t.Context(), path.Root("password"), &password)
+			assert.True(t, password.IsNull(), "password drift is planned as a password change")
+		}
+	}
+}
+
+func TestAtuinUserReadDeleted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"reason": "user not found"}`))
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+	resp := testAtuinUserRead(t, r, testAtuinUserState(t))
+
+	assert.False(t, resp.Diagnostics.HasError())
+	assert.True(t, resp.State.Raw.IsNull(), "deleted user is removed from state")
+}
+
+func TestAtuinUserUpdatePasswordDriftRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"reason": "password is not correct"}`))
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+	state := testAtuinUserState(t)
+	state.Password = types.StringNull()
+
+	plan := state
+	plan.Password = types.StringValue("swordfish")
+
+	// The rejected password must not end up in state
+	resp := testAtuinUserUpdate(t, r, state, plan)
+	assert.True(t, resp.Diagnostics.HasError())
+
+	var data AtuinUserModel
+	resp.State.Get(t.Context(), &data)
+	assert.Equal(t, state, data)
+}
+
+func testAtuinUserModifyPlan(t *testing.T, r *AtuinUser, state, plan AtuinUserModel) *fwresource.ModifyPlanResponse {
+	t.Helper()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	tfState := tfsdk.State{Schema: schemaResp.Schema}
+	if diags := tfState.Set(t.Context(), &state); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	tfPlan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := tfPlan.Set(t.Context(), &plan); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	tfConfig := tfsdk.Config{Schema: schemaResp.Schema, Raw: tfPlan.Raw}
+
+	resp := &fwresource.ModifyPlanResponse{Plan: tfPlan}
+	r.ModifyPlan(t.Context(), fwresource.ModifyPlanRequest{State: tfState, Plan: tfPlan, Config: tfConfig}, resp)
+	return resp
+}
+
+func TestAtuinUserEmailChange(t *testing.T) {
+	r := &AtuinUser{}
+	state := testAtuinUserState(t)
+
+	for policy, want := range map[string]struct {
+		replace  bool
+		warnings int
+	}{
+		emailChangeRequiresReplace: {replace: true},
+		emailChangeWarn:            {warnings: 1},
+		emailChangeIgnore:          {},
+	} {
+		state.EmailChange = types.StringValue(policy)
+		plan := state
+		plan.Email = types.StringValue("wizzard@uu.am")
+
+		resp := testAtuinUserModifyPlan(t, r, state, plan)
+
+		assert.False(t, resp.Diagnostics.HasError(), policy)
+		assert.Equal(t, want.warnings, resp.Diagnostics.WarningsCount(), policy)
+		assert.Equal(t, want.replace, slices.ContainsFunc(resp.RequiresReplace, func(p path.Path) bool { return p.Equal(path.Root("email")) }), policy)
+
+		// An unchanged email is not affected by the policy
+		resp = testAtuinUserModifyPlan(t, r, state, state)
+		assert.Empty(t, resp.RequiresReplace, policy)
+		assert.Zero(t, resp.Diagnostics.WarningsCount(), policy)
+	}
+}
+
+func TestAtuinUserUpdateEmailFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"session": "token"}`))
+	})
+	mux.HandleFunc("PATCH /account/password", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("PATCH /account/email", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+	state := testAtuinUserState(t)
+
+	plan := state
+	plan.Password = types.StringValue("octarine")
+	plan.Email = types.StringValue("wizzard@uu.am")
+	plan.KeyVersion = types.Int64Value(1)
+
+	resp := testAtuinUserUpdate(t, r, state, plan)
+	assert.True(t, resp.Diagnostics.HasError())
+
+	// The password was changed on the server, but the email was not, and the key was not rotated
+	want := state
+	want.Password = plan.Password
+	var data AtuinUserModel
+	resp.State.Get(t.Context(), &data)
+	assert.Equal(t, want, data)
+}
+
+func TestAtuinUserDeleteSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"username": "rincewind"}`))
+	}))
+	defer server.Close()
+
+	// The session of the provider is only used for the same user
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL, atuin.WithSessionToken("provider-token"))}
+
+	token, diags := r.deleteSession(t.Context(), "rincewind")
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "provider-token", token)
+
+	_, diags = r.deleteSession(t.Context(), "twoflower")
+	assert.True(t, diags.HasError())
+
+	// Without a session of the provider, the user cannot be deleted
+	r = &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+
+	_, diags = r.deleteSession(t.Context(), "rincewind")
+	if assert.True(t, diags.HasError()) {
+		assert.Equal(t, "Missing Atuin Session", diags.Errors()[0].Summary())
+	}
+}
+
+func TestAtuinUserReadWriteOnlyPassword(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEqual(t, "/login", r.URL.Path, "a user with a write-only password cannot login")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"reason": "user not found"}`))
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+	data := testAtuinUserState(t)
+	data.Password = types.StringNull()
+	data.PasswordWOVersion = types.Int64Value(1)
+
+	resp := testAtuinUserRead(t, r, data)
+
+	assert.False(t, resp.Diagnostics.HasError())
+	assert.True(t, resp.State.Raw.IsNull(), "deleted user is removed from state")
+}
+
+func TestAtuinUserWriteOnlyPasswordVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var login map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&login)
+		if login["password"] != "octarine" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"reason": "password is not correct"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"session": "token"}`))
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+
+	state := testAtuinUserState(t)
+	state.Password = types.StringNull()
+	state.PasswordWOVersion = types.Int64Value(1)
+
+	tests := []struct {
+		name        string
+		password    string
+		version     int64
+		expectError bool
+	}{
+		{"version unchanged", "swordfish", 1, false},
+		{"changed with the Atuin CLI", "octarine", 2, false},
+		{"not changed with the Atuin CLI", "swordfish", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := state
+			plan.PasswordWO = types.StringValue(tt.password)
+			plan.PasswordWOVersion = types.Int64Value(tt.version)
+
+			resp := testAtuinUserModifyPlan(t, r, state, plan)
+			if !tt.expectError {
+				assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+				return
+			}
+			if assert.Len(t, resp.Diagnostics.Errors(), 1) {
+				assert.Equal(t, path.Root("password_wo"), resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath).Path())
+			}
+		})
+	}
+}
+
+func TestAccAtuinUserWriteOnlyPassword(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "atuin_user" "test" {
+  username            = "granny"
+  password_wo         = "pa$$word"
+  password_wo_version = 1
+  email               = "granny@example.com"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("atuin_user.test", "password"),
+					resource.TestCheckNoResourceAttr("atuin_user.test", "password_wo"),
+					resource.TestCheckResourceAttr("atuin_user.test", "password_wo_version", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAtuinUserEncryptionKey(t *testing.T) {
+	key, err := atuin.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic, err := atuin.ConvertEncryptionKeyToBip39(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &AtuinUser{}
+	state := testAtuinUserState(t)
+	state.Base64Key = types.StringValue("old-key")
+	state.Bip39Key = types.StringValue("old mnemonic")
+
+	// The computed keys mirror the configured key, in any form
+	plan := state
+	plan.EncryptionKey = types.StringValue(mnemonic)
+
+	resp := testAtuinUserModifyPlan(t, r, state, plan)
+	assert.False(t, resp.Diagnostics.HasError())
+
+	var planned AtuinUserModel
+	resp.Plan.Get(t.Context(), &planned)
+	assert.Equal(t, key, planned.Base64Key.ValueString())
+	assert.Equal(t, mnemonic, planned.Bip39Key.ValueString())
+
+	plan.EncryptionKey = types.StringValue("not a key")
+	resp = testAtuinUserModifyPlan(t, r, state, plan)
+	assert.True(t, resp.Diagnostics.HasError())
+}
+
+func TestAccAtuinUserEncryptionKey(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "atuin_user" "test" {
+  username       = "magrat"
+  password       = "pa$$word"
+  email          = "magrat@example.com"
+  encryption_key = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atuin_user.test", "base64_key", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="),
+				),
+			},
+		},
+	})
+}
+
+func TestAtuinUserValidatePasswordStrength(t *testing.T) {
+	r := &AtuinUser{}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name          string
+		password      types.String
+		passwordWO    types.String
+		minStrength   types.Int64
+		expectedError string
+	}{
+		{"no minimum", types.StringValue("swordfish"), types.StringNull(), types.Int64Null(), ""},
+		{"weak password", types.StringValue("swordfish"), types.StringNull(), types.Int64Value(3), "password"},
+		{"password based on username", types.StringValue("rincewind1"), types.StringNull(), types.Int64Value(1), "password"},
+		{"weak write-only password", types.StringNull(), types.StringValue("swordfish"), types.Int64Value(3), "password_wo"},
+		{"strong password", types.StringValue("correct horse battery staple"), types.StringNull(), types.Int64Value(4), ""},
+		{"unknown password", types.StringUnknown(), types.StringNull(), types.Int64Value(4), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testAtuinUserState(t)
+			data.Password = tt.password
+			data.PasswordWO = tt.passwordWO
+			data.MinPasswordStrength = tt.minStrength
+			if !tt.passwordWO.IsNull() {
+				data.PasswordWOVersion = types.Int64Value(1)
+			}
+
+			state := tfsdk.State{Schema: schemaResp.Schema}
+			if diags := state.Set(t.Context(), &data); diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			resp := &fwresource.ValidateConfigResponse{}
+			r.ValidateConfig(t.Context(), fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}}, resp)
+
+			if tt.expectedError == "" {
+				assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+				return
+			}
+			if assert.Len(t, resp.Diagnostics.Errors(), 1) {
+				assert.Equal(t, "Weak Password", resp.Diagnostics.Errors()[0].Summary())
+				assert.Equal(t, path.Root(tt.expectedError), resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath).Path())
+			}
+		})
+	}
+}
+
+func TestAtuinUserCreateTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Registration hangs until the client gives up, which the server notices once the body is read
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	r := &AtuinUser{client: atuin.NewAtuinClient(server.URL)}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	configuredTimeouts := nullAtuinUserTimeouts(t.Context())
+	configuredTimeouts.Object = types.ObjectValueMust(configuredTimeouts.Object.AttributeTypes(t.Context()), map[string]attr.Value{
+		"create": types.StringValue("100ms"),
+		"read":   types.StringNull(),
+		"update": types.StringNull(),
+		"delete": types.StringNull(),
+	})
+
+	data := testAtuinUserState(t)
+	data.Base64Key = types.StringUnknown()
+	data.Bip39Key = types.StringUnknown()
+	data.Timeouts = configuredTimeouts
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := plan.Set(t.Context(), &data); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	start := time.Now()
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(t.Context(), fwresource.CreateRequest{Plan: plan, Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: plan.Raw}}, resp)
+
+	assert.True(t, resp.Diagnostics.HasError())
+	assert.Contains(t, resp.Diagnostics.Errors()[0].Detail(), "context deadline exceeded")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestAtuinUserGeneratedPassword(t *testing.T) {
+	keepers := func(v string) types.Map {
+		return types.MapValueMust(types.StringType, map[string]attr.Value{"rotated": types.StringValue(v)})
+	}
+
+	r := &AtuinUser{}
+	state := testAtuinUserState(t)
+	state.Password = types.StringValue("generated")
+	state.PasswordKeepers = keepers("2026-01")
+
+	tests := []struct {
+		name     string
+		keepers  types.Map
+		password types.String
+		expected types.String
+	}{
+		{"keepers unchanged", keepers("2026-01"), types.StringNull(), types.StringValue("generated")},
+		{"keepers changed", keepers("2026-02"), types.StringNull(), types.StringUnknown()},
+		{"keepers removed", types.MapNull(types.StringType), types.StringNull(), types.StringUnknown()},
+		{"password configured", keepers("2026-02"), types.StringValue("swordfish"), types.StringValue("swordfish")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := state
+			plan.PasswordKeepers = tt.keepers
+			plan.Password = tt.password
+
+			resp := testAtuinUserModifyPlan(t, r, state, plan)
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+			var planned AtuinUserModel
+			resp.Plan.Get(t.Context(), &planned)
+			assert.Equal(t, tt.expected, planned.Password)
+		})
+	}
+}
+
+func TestAtuinUserGeneratedPasswordDrift(t *testing.T) {
+	r := &AtuinUser{}
+
+	// The password was cleared by drift, or was write-only before
+	state := testAtuinUserState(t)
+	state.Password = types.StringNull()
+
+	plan := state
+	resp := testAtuinUserModifyPlan(t, r, state, plan)
+
+	// A new password cannot be set without the current one
+	if assert.Len(t, resp.Diagnostics.Errors(), 1) {
+		assert.Equal(t, "Unable to Generate Atuin Password", resp.Diagnostics.Errors()[0].Summary())
+		assert.Equal(t, path.Root("password"), resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath).Path())
+	}
+
+	// Once set to the current password, it is kept when the configuration leaves it out again
+	state.Password = types.StringValue("octarine")
+	resp = testAtuinUserModifyPlan(t, r, state, plan)
+	assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+	var planned AtuinUserModel
+	resp.Plan.Get(t.Context(), &planned)
+	assert.Equal(t, types.StringValue("octarine"), planned.Password)
+}
+
+func TestAtuinUserValidatePasswordGeneration(t *testing.T) {
+	r := &AtuinUser{}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	disabled := &atuinPasswordGenerationModel{
+		Length:  types.Int64Null(),
+		Lower:   types.BoolValue(false),
+		Upper:   types.BoolValue(false),
+		Numeric: types.BoolValue(false),
+		Special: types.BoolValue(false),
+	}
+
+	tests := []struct {
+		name          string
+		password      types.String
+		generation    *atuinPasswordGenerationModel
+		keepers       types.Map
+		expectedError string
+	}{
+		{"generated", types.StringNull(), nil, types.MapNull(types.StringType), ""},
+		{"generated with keepers", types.StringNull(), &atuinPasswordGenerationModel{Length: types.Int64Value(20)}, types.MapValueMust(types.StringType, map[string]attr.Value{}), ""},
+		{"configured with generation", types.StringValue("swordfish"), &atuinPasswordGenerationModel{Length: types.Int64Value(20)}, types.MapNull(types.StringType), "password_generation"},
+		{"configured with keepers", types.StringValue("swordfish"), nil, types.MapValueMust(types.StringType, map[string]attr.Value{}), "password_keepers"},
+		{"no character class", types.StringNull(), disabled, types.MapNull(types.StringType), "password_generation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generation := types.ObjectNull(passwordGenerationAttrTypes)
+			if tt.generation != nil {
+				var diags diag.Diagnostics
+				generation, diags = types.ObjectValueFrom(t.Context(), passwordGenerationAttrTypes, tt.generation)
+				if diags.HasError() {
+					t.Fatal(diags)
+				}
+			}
+
+			data := testAtuinUserState(t)
+			data.Password = tt.password
+			data.PasswordGeneration = generation
+			data.PasswordKeepers = tt.keepers
+
+			state := tfsdk.State{Schema: schemaResp.Schema}
+			if diags := state.Set(t.Context(), &data); diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			resp := &fwresource.ValidateConfigResponse{}
+			r.ValidateConfig(t.Context(), fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}}, resp)
+
+			if tt.expectedError == "" {
+				assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+				return
+			}
+			if assert.Len(t, resp.Diagnostics.Errors(), 1) {
+				assert.Equal(t, path.Root(tt.expectedError), resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath).Path())
+			}
+		})
+	}
+}
+
+func TestAccAtuinUserGeneratedPassword(t *testing.T) {
+	var firstPassword string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAtuinUserGeneratedPasswordConfig("detritus", "first"),
+				Check: resource.TestCheckResourceAttrWith("atuin_user.test", "password", func(value string) error {
+					if len(value) != 20 {
+						return fmt.Errorf("expected a generated password of 20 characters, got %d", len(value))
+					}
+					firstPassword = value
+					return nil
+				}),
+			},
+			{
+				Config: testAccAtuinUserGeneratedPasswordConfig("detritus", "second"),
+				Check: resource.TestCheckResourceAttrWith("atuin_user.test", "password", func(value string) error {
+					if value == firstPassword {
+						return fmt.Errorf("expected the password to be regenerated")
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func testAccAtuinUserGeneratedPasswordConfig(username, keeper string) string {
+	return fmt.Sprintf(`
+resource "atuin_user" "test" {
+  username = %[1]q
+  email    = "%[1]s@example.com"
+
+  password_generation = {
+    length = 20
+  }
+
+  password_keepers = {
+    keeper = %[2]q
+  }
+}
+`, username, keeper)
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithUpgradeState = &AtuinUser{}
+
+// atuinUserSchemaVersion is the version of the atuin_user schema. Bump it on every change that existing
+// states cannot be read with as is, and add an upgrader from the previous version to UpgradeState.
+const atuinUserSchemaVersion = 1
+
+// atuinUserModelV0 describes the data model of version 0 of the atuin_user schema, the schema of the
+// releases before the schema was versioned.
+type atuinUserModelV0 struct {
+	Username  types.String `tfsdk:"username"`
+	Password  types.String `tfsdk:"password"`
+	Email     types.String `tfsdk:"email"`
+	Base64Key types.String `tfsdk:"base64_key"`
+	Bip39Key  types.String `tfsdk:"bip39_key"`
+}
+
+// atuinUserSchemaV0 is version 0 of the atuin_user schema, as released before the schema was versioned.
+// It only has to decode prior states.
+func atuinUserSchemaV0() *schema.Schema {
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"username":   schema.StringAttribute{Required: true},
+			"password":   schema.StringAttribute{Required: true, Sensitive: true},
+			"email":      schema.StringAttribute{Required: true},
+			"base64_key": schema.StringAttribute{Computed: true, Sensitive: true},
+			"bip39_key":  schema.StringAttribute{Computed: true, Sensitive: true},
+		},
+	}
+}
+
+// nullAtuinUserTimeouts returns the value of an absent timeouts block.
+func nullAtuinUserTimeouts(ctx context.Context) timeouts.Value {
+	return timeouts.Value{
+		Object: types.ObjectNull(timeouts.BlockAll(ctx).Type().(timeouts.Type).AttributeTypes()),
+	}
+}
+
+func (r *AtuinUser) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   atuinUserSchemaV0(),
+			StateUpgrader: upgradeAtuinUserStateV0,
+		},
+	}
+}
+
+// upgradeAtuinUserStateV0 fills in the attributes that were added since version 0 with the values a
+// current release writes for a user created with the same configuration.
+func upgradeAtuinUserStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior atuinUserModelV0
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := AtuinUserModel{
+		Username: prior.Username,
+		// Users created before the username prefix have the username of the account as username
+		FullUsername:        prior.Username,
+		Password:            prior.Password,
+		PasswordGeneration:  types.ObjectNull(passwordGenerationAttrTypes),
+		PasswordKeepers:     types.MapNull(types.StringType),
+		PasswordWO:          types.StringNull(),
+		PasswordWOVersion:   types.Int64Null(),
+		MinPasswordStrength: types.Int64Null(),
+		Email:               prior.Email,
+		EncryptionKey:       types.StringNull(),
+		Base64Key:           prior.Base64Key,
+		Bip39Key:            prior.Bip39Key,
+		KeyVersion:          types.Int64Value(0),
+		OnPasswordDrift:     types.StringValue(passwordDriftWarn),
+		EmailChange:         types.StringValue(emailChangeWarn),
+		Timeouts:            nullAtuinUserTimeouts(ctx),
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+}
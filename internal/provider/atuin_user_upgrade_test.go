@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"testing"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtuinUserUpgradeState(t *testing.T) {
+	r := &AtuinUser{}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	upgraders := r.UpgradeState(t.Context())
+	for version := range int64(atuinUserSchemaVersion) {
+		assert.Contains(t, upgraders, version, "missing state upgrader from version %d", version)
+	}
+
+	tests := []struct {
+		name     string
+		version  int64
+		rawState string
+		expected AtuinUserModel
+	}{
+		{
+			// The attributes of a state written by the last release before the schema was versioned
+			name:    "v0",
+			version: 0,
+			rawState: `{
+				"base64_key": "uGVDPXo6xI73HZXJ2/80R+FQRreLcVWHVsHgUO8tI4s=",
+				"bip39_key": "reveal claw soon virtual proof electric symbol razor six that snack more bench cash taste hotel few deny race scheme auction notable mixed island",
+				"email": "rincewind@uu.am",
+				"password": "swordfish",
+				"username": "rincewind"
+			}`,
+			expected: AtuinUserModel{
+				Username:           types.StringValue("rincewind"),
+				FullUsername:       types.StringValue("rincewind"),
+				Password:           types.StringValue("swordfish"),
+				PasswordWO:         types.StringNull(),
+				PasswordWOVersion:  types.Int64Null(),
+				Email:              types.StringValue("rincewind@uu.am"),
+				EncryptionKey:      types.StringNull(),
+				Base64Key:          types.StringValue("uGVDPXo6xI73HZXJ2/80R+FQRreLcVWHVsHgUO8tI4s="),
+				Bip39Key:           types.StringValue("reveal claw soon virtual proof electric symbol razor six that snack more bench cash taste hotel few deny race scheme auction notable mixed island"),
+				KeyVersion:         types.Int64Value(0),
+				PasswordGeneration: types.ObjectNull(passwordGenerationAttrTypes),
+				PasswordKeepers:    types.MapNull(types.StringType),
+				OnPasswordDrift:    types.StringValue(passwordDriftWarn),
+				EmailChange:        types.StringValue(emailChangeWarn),
+				Timeouts:           nullAtuinUserTimeouts(t.Context()),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrader := upgraders[tt.version]
+
+			priorType := upgrader.PriorSchema.Type().TerraformType(t.Context())
+			raw, err := tftypes.ValueFromJSONWithOpts([]byte(tt.rawState), priorType, tftypes.ValueFromJSONOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := fwresource.UpgradeStateRequest{
+				State: &tfsdk.State{Schema: *upgrader.PriorSchema, Raw: raw},
+			}
+			resp := &fwresource.UpgradeStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(t.Context()), nil)},
+			}
+			upgrader.StateUpgrader(t.Context(), req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+
+			var data AtuinUserModel
+			resp.State.Get(t.Context(), &data)
+			assert.Equal(t, tt.expected, data)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// atuinAuthModel maps the provider auth block to a Go type.
+type atuinAuthModel struct {
+	Username        types.String `tfsdk:"username"`
+	Password        types.String `tfsdk:"password"`
+	SessionToken    types.String `tfsdk:"session_token"`
+	UseLocalSession types.Bool   `tfsdk:"use_local_session"`
+}
+
+// configureAuth authenticates the client with the auth block of the provider, falling back to the
+// ATUIN_USERNAME, ATUIN_PASSWORD and ATUIN_PROVIDER_SESSION_TOKEN environment variables. A username
+// and password take precedence over a session token, which takes precedence over the local login of the
+// Atuin client when use_local_session is set. A session token from the environment does not, as
+// use_local_session is set explicitly. Without any credentials, the client is left unauthenticated.
+// ATUIN_SESSION is not read, as `atuin init` exports it as the id of the shell session.
+func configureAuth(ctx context.Context, client *atuin.AtuinClient, config *atuinAuthModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	username := os.Getenv("ATUIN_USERNAME")
+	password := os.Getenv("ATUIN_PASSWORD")
+	sessionToken := os.Getenv("ATUIN_PROVIDER_SESSION_TOKEN")
+	useLocalSession := false
+
+	if config != nil {
+		for name, value := range map[string]types.String{"username": config.Username, "password": config.Password, "session_token": config.SessionToken} {
+			if value.IsUnknown() {
+				diags.AddAttributeError(
+					path.Root("auth").AtName(name),
+					"Unknown Atuin Credentials",
+					"The provider cannot authenticate with the Atuin API as there is an unknown configuration value for the "+name+". "+
+						"Either target apply the source of the value first, set the value statically in the configuration, or use an environment variable.",
+				)
+			}
+		}
+
+		if !config.Username.IsNull() {
+			username = config.Username.ValueString()
+		}
+		if !config.Password.IsNull() {
+			password = config.Password.ValueString()
+		}
+		if !config.SessionToken.IsNull() {
+			sessionToken = config.SessionToken.ValueString()
+		}
+
+		if config.UseLocalSession.IsUnknown() {
+			diags.AddAttributeError(
+				path.Root("auth").AtName("use_local_session"),
+				"Unknown Atuin Credentials",
+				"The provider cannot authenticate with the Atuin API as there is an unknown configuration value for use_local_session. "+
+					"Either target apply the source of the value first or set the value statically in the configuration.",
+			)
+		}
+		useLocalSession = config.UseLocalSession.ValueBool()
+
+		if useLocalSession && config.SessionToken.IsNull() {
+			sessionToken = ""
+		}
+	}
+
+	if diags.HasError() {
+		return diags
+	}
+
+	switch {
+	case username != "" && password != "":
+		if err := client.Authenticate(ctx, username, password); err != nil {
+			diags.AddAttributeError(
+				path.Root("auth"),
+				"Unable to Authenticate Atuin User",
+				fmt.Sprintf("The provider cannot login Atuin user %q: %s", username, err),
+			)
+		}
+	case username != "" || password != "":
+		diags.AddAttributeError(
+			path.Root("auth"),
+			"Incomplete Atuin Credentials",
+			"Both a username and a password are required to login. Set both in the auth block, or use the ATUIN_USERNAME and ATUIN_PASSWORD environment variables.",
+		)
+	case sessionToken != "":
+		atuin.WithSessionToken(sessionToken)(client)
+	case useLocalSession:
+		login, err := localLogin()
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("auth").AtName("use_local_session"),
+				"Unable to Read Local Atuin Login",
+				fmt.Sprintf("The provider cannot read the session of the Atuin client: %s", err),
+			)
+			return diags
+		}
+
+		tflog.Debug(ctx, "Using local Atuin login", map[string]any{"atuin_key_found": login.Key != ""})
+
+		atuin.WithSessionToken(login.SessionToken)(client)
+		atuin.WithEncryptionKey(login.Key)(client)
+	}
+
+	return diags
+}
+
+// localLogin reads the login of the Atuin client, using the paths of its configuration file.
+func localLogin() (*atuinLocalLogin, error) {
+	clientConfig, _, err := readAtuinClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return readAtuinLocalLogin(clientConfig)
+}
+
+// resolveSessionToken returns a session token for the credentials of a resource or data source, or the session
+// of the provider when neither the username nor the password is set.
+func resolveSessionToken(ctx context.Context, client *atuin.AtuinClient, username, password types.String) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if username.IsNull() && password.IsNull() {
+		if client.SessionToken() == "" {
+			diags.AddError(
+				"Missing Atuin Credentials",
+				"Set a username and password, or configure the auth block of the provider.",
+			)
+		}
+		return client.SessionToken(), diags
+	}
+
+	if username.IsNull() || password.IsNull() {
+		diags.AddError(
+			"Incomplete Atuin Credentials",
+			"Both a username and a password are required to login. Omit both to use the auth block of the provider.",
+		)
+		return "", diags
+	}
+
+	token, err := client.Login(ctx, username.ValueString(), password.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to login user: %s", err))
+	}
+	return token, diags
+}
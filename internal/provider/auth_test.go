@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLoginServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"session": "login-token"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConfigureAuth(t *testing.T) {
+	server := newTestLoginServer(t)
+
+	t.Setenv("ATUIN_USERNAME", "")
+	t.Setenv("ATUIN_PASSWORD", "")
+	t.Setenv("ATUIN_PROVIDER_SESSION_TOKEN", "env-token")
+
+	// Without an auth block, the session token is read from the environment
+	client := atuin.NewAtuinClient(server.URL)
+	diags := configureAuth(t.Context(), client, nil)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "env-token", client.SessionToken())
+
+	// A username and password take precedence over a session token
+	client = atuin.NewAtuinClient(server.URL)
+	diags = configureAuth(t.Context(), client, &atuinAuthModel{
+		Username:     types.StringValue("rincewind"),
+		Password:     types.StringValue("swordfish"),
+		SessionToken: types.StringNull(),
+	})
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "login-token", client.SessionToken())
+
+	// A username without a password is an error
+	client = atuin.NewAtuinClient(server.URL)
+	diags = configureAuth(t.Context(), client, &atuinAuthModel{
+		Username:     types.StringValue("rincewind"),
+		Password:     types.StringNull(),
+		SessionToken: types.StringNull(),
+	})
+	assert.True(t, diags.HasError())
+
+	// The local login is used when use_local_session is set, even in a shell initialized by `atuin init`,
+	// and with a session token in the environment
+	dataDir := t.TempDir()
+	t.Setenv("ATUIN_CONFIG_DIR", t.TempDir())
+	t.Setenv("ATUIN_DATA_DIR", dataDir)
+	t.Setenv("ATUIN_SESSION_PATH", "")
+	t.Setenv("ATUIN_KEY_PATH", "")
+	t.Setenv("ATUIN_SESSION", "019a0c4e8f2b7d3a9c5e1f6b8d4a2c7e")
+	if err := os.WriteFile(filepath.Join(dataDir, "session"), []byte("local-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client = atuin.NewAtuinClient(server.URL)
+	diags = configureAuth(t.Context(), client, &atuinAuthModel{
+		Username:        types.StringNull(),
+		Password:        types.StringNull(),
+		SessionToken:    types.StringNull(),
+		UseLocalSession: types.BoolValue(true),
+	})
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "local-token", client.SessionToken())
+	assert.Empty(t, client.EncryptionKey())
+
+	// A session token in the configuration takes precedence over the local login
+	client = atuin.NewAtuinClient(server.URL)
+	diags = configureAuth(t.Context(), client, &atuinAuthModel{
+		Username:        types.StringNull(),
+		Password:        types.StringNull(),
+		SessionToken:    types.StringValue("config-token"),
+		UseLocalSession: types.BoolValue(true),
+	})
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "config-token", client.SessionToken())
+}
+
+func TestResolveSessionToken(t *testing.T) {
+	server := newTestLoginServer(t)
+
+	client := atuin.NewAtuinClient(server.URL)
+	_, diags := resolveSessionToken(t.Context(), client, types.StringNull(), types.StringNull())
+	assert.True(t, diags.HasError(), "no credentials and no provider session")
+
+	client = atuin.NewAtuinClient(server.URL, atuin.WithSessionToken("provider-token"))
+	token, diags := resolveSessionToken(t.Context(), client, types.StringNull(), types.StringNull())
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "provider-token", token)
+
+	token, diags = resolveSessionToken(t.Context(), client, types.StringValue("rincewind"), types.StringValue("swordfish"))
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "login-token", token)
+
+	_, diags = resolveSessionToken(t.Context(), client, types.StringValue("rincewind"), types.StringNull())
+	assert.True(t, diags.HasError())
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+)
+
+// emailTemplateUsername is replaced by the full username in the email template.
+const emailTemplateUsername = "{{username}}"
+
+// atuinResourceData is handed to resources by the provider: the shared client, and the conventions for
+// the accounts they manage.
+type atuinResourceData struct {
+	client *atuin.AtuinClient
+	naming atuinNaming
+}
+
+// atuinNaming holds the provider-level conventions for usernames and emails of Atuin users.
+type atuinNaming struct {
+	usernamePrefix string
+	emailTemplate  string
+}
+
+// validateNaming checks the username prefix and email template of the provider configuration.
+func validateNaming(usernamePrefix, emailTemplate string) (prefixErr, templateErr error) {
+	if usernamePrefix != "" {
+		prefixErr = atuin.ValidateUsername(usernamePrefix)
+	}
+
+	if emailTemplate != "" && strings.Contains(strings.ReplaceAll(emailTemplate, emailTemplateUsername, ""), "{{") {
+		templateErr = errors.New("the only placeholder supported in the email template is " + emailTemplateUsername)
+	}
+
+	return prefixErr, templateErr
+}
+
+// fullUsername returns the username with the prefix, unless it already starts with it.
+func (n atuinNaming) fullUsername(username string) string {
+	if strings.HasPrefix(username, n.usernamePrefix) {
+		return username
+	}
+	return n.usernamePrefix + username
+}
+
+// email returns the email derived from the template for the full username, or false if there is no template.
+func (n atuinNaming) email(fullUsername string) (string, bool) {
+	if n.emailTemplate == "" {
+		return "", false
+	}
+	return strings.ReplaceAll(n.emailTemplate, emailTemplateUsername, fullUsername), true
+}
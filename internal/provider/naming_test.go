@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtuinNaming(t *testing.T) {
+	naming := atuinNaming{usernamePrefix: "watch-", emailTemplate: "{{username}}@atuin.example.com"}
+
+	assert.Equal(t, "watch-vimes", naming.fullUsername("vimes"))
+	assert.Equal(t, "watch-vimes", naming.fullUsername("watch-vimes"))
+
+	email, ok := naming.email("watch-vimes")
+	assert.True(t, ok)
+	assert.Equal(t, "watch-vimes@atuin.example.com", email)
+
+	_, ok = atuinNaming{}.email("vimes")
+	assert.False(t, ok)
+	assert.Equal(t, "vimes", atuinNaming{}.fullUsername("vimes"))
+}
+
+func TestValidateNaming(t *testing.T) {
+	prefixErr, templateErr := validateNaming("watch-", "{{username}}@atuin.example.com")
+	assert.NoError(t, prefixErr)
+	assert.NoError(t, templateErr)
+
+	prefixErr, templateErr = validateNaming("watch.", "{{user}}@atuin.example.com")
+	assert.Error(t, prefixErr)
+	assert.Error(t, templateErr)
+}
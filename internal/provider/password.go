@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Character classes of generated passwords.
+const (
+	passwordLowerCharacters   = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpperCharacters   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordNumericCharacters = "0123456789"
+	passwordSpecialCharacters = "!@#$%&*()-_=+[]{}<>:?"
+)
+
+// defaultPasswordLength is the length of generated passwords, unless configured.
+const defaultPasswordLength = 32
+
+// atuinPasswordGenerationModel describes how the password of an atuin_user without a configured
+// password is generated. Null character classes are included.
+type atuinPasswordGenerationModel struct {
+	Length  types.Int64 `tfsdk:"length"`
+	Lower   types.Bool  `tfsdk:"lower"`
+	Upper   types.Bool  `tfsdk:"upper"`
+	Numeric types.Bool  `tfsdk:"numeric"`
+	Special types.Bool  `tfsdk:"special"`
+}
+
+// passwordGenerationAttrTypes are the attribute types of the password_generation attribute.
+var passwordGenerationAttrTypes = map[string]attr.Type{
+	"length":  types.Int64Type,
+	"lower":   types.BoolType,
+	"upper":   types.BoolType,
+	"numeric": types.BoolType,
+	"special": types.BoolType,
+}
+
+// passwordGeneration returns the password generation settings, or nil when there are none yet.
+func passwordGeneration(ctx context.Context, value types.Object) (*atuinPasswordGenerationModel, diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
+	}
+
+	var config atuinPasswordGenerationModel
+	diags := value.As(ctx, &config, basetypes.ObjectAsOptions{})
+	return &config, diags
+}
+
+// characterClasses returns the enabled character classes, which are all of them without a configuration.
+func (m *atuinPasswordGenerationModel) characterClasses() []string {
+	if m == nil {
+		return []string{passwordLowerCharacters, passwordUpperCharacters, passwordNumericCharacters, passwordSpecialCharacters}
+	}
+
+	var classes []string
+	for _, class := range []struct {
+		enabled    types.Bool
+		characters string
+	}{
+		{m.Lower, passwordLowerCharacters},
+		{m.Upper, passwordUpperCharacters},
+		{m.Numeric, passwordNumericCharacters},
+		{m.Special, passwordSpecialCharacters},
+	} {
+		if class.enabled.IsNull() || class.enabled.ValueBool() {
+			classes = append(classes, class.characters)
+		}
+	}
+	return classes
+}
+
+func (m *atuinPasswordGenerationModel) length() int {
+	if m == nil || m.Length.IsNull() {
+		return defaultPasswordLength
+	}
+	return int(m.Length.ValueInt64())
+}
+
+// generatePassword generates a password with crypto/rand, with at least one character of each enabled
+// character class.
+func generatePassword(config *atuinPasswordGenerationModel) (string, error) {
+	classes := config.characterClasses()
+	if len(classes) == 0 {
+		return "", errors.New("at least one character class must be enabled")
+	}
+
+	length := config.length()
+	if length < len(classes) {
+		return "", errors.New("the password length must be at least the number of enabled character classes")
+	}
+
+	var all string
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		c, err := randomCharacter(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+		all += class
+	}
+
+	for len(password) < length {
+		c, err := randomCharacter(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Shuffle, so that the guaranteed characters are not always in front
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomCharacter(characters string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(characters))))
+	if err != nil {
+		return 0, err
+	}
+	return characters[i.Int64()], nil
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := generatePassword(nil)
+	assert.NoError(t, err)
+	assert.Len(t, password, defaultPasswordLength)
+	for _, class := range []string{passwordLowerCharacters, passwordUpperCharacters, passwordNumericCharacters, passwordSpecialCharacters} {
+		assert.True(t, strings.ContainsAny(password, class), "password %q has a character of %q", password, class)
+	}
+
+	other, err := generatePassword(nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, password, other)
+
+	password, err = generatePassword(&atuinPasswordGenerationModel{
+		Length:  types.Int64Value(12),
+		Lower:   types.BoolNull(),
+		Upper:   types.BoolValue(false),
+		Numeric: types.BoolValue(true),
+		Special: types.BoolValue(false),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, password, 12)
+	assert.Empty(t, strings.Trim(password, passwordLowerCharacters+passwordNumericCharacters))
+	assert.True(t, strings.ContainsAny(password, passwordLowerCharacters))
+	assert.True(t, strings.ContainsAny(password, passwordNumericCharacters))
+
+	_, err = generatePassword(&atuinPasswordGenerationModel{
+		Length:  types.Int64Null(),
+		Lower:   types.BoolValue(false),
+		Upper:   types.BoolValue(false),
+		Numeric: types.BoolValue(false),
+		Special: types.BoolValue(false),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// unknownIfChanged marks a computed string attribute as unknown when the value of another attribute
+// changes, so the plan shows that it will be recomputed. Changes from a null prior state value are
+// ignored, as they happen when upgrading from a provider version without the trigger attribute.
+func unknownIfChanged(trigger path.Path) planmodifier.String {
+	return unknownIfChangedModifier{trigger: trigger}
+}
+
+type unknownIfChangedModifier struct {
+	trigger path.Path
+}
+
+func (m unknownIfChangedModifier) Description(_ context.Context) string {
+	return fmt.Sprintf("The value will be recomputed when %s changes.", m.trigger)
+}
+
+func (m unknownIfChangedModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m unknownIfChangedModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to compare against on create and destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planValue, stateValue attr.Value
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, m.trigger, &planValue)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, m.trigger, &stateValue)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if stateValue.IsNull() || planValue.Equal(stateValue) {
+		return
+	}
+
+	resp.PlanValue = types.StringUnknown()
+}
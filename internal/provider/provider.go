@@ -2,9 +2,11 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
 	atuin "terraform-provider-atuin/internal/atuin_client"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
@@ -38,7 +40,14 @@ type atuinProvider struct {
 
 // atuinProviderModel maps provider schema data to a Go type.
 type atuinProviderModel struct {
-	Host types.String `tfsdk:"host"`
+	Host                  types.String       `tfsdk:"host"`
+	RequiredServerVersion types.String       `tfsdk:"required_server_version"`
+	RequestsPerSecond     types.Float64      `tfsdk:"requests_per_second"`
+	Burst                 types.Int64        `tfsdk:"burst"`
+	UsernamePrefix        types.String       `tfsdk:"username_prefix"`
+	EmailTemplate         types.String       `tfsdk:"email_template"`
+	Tracing               *atuinTracingModel `tfsdk:"tracing"`
+	Auth                  *atuinAuthModel    `tfsdk:"auth"`
 }
 
 // Metadata returns the provider type name.
@@ -52,7 +61,66 @@ func (p *atuinProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
-				Optional: true,
+				MarkdownDescription: "URL of the Atuin API. May also be provided via the `ATUIN_HOST` environment variable. Defaults to the `sync_address` of the Atuin client configuration file, and to the public Atuin API only when that file does not exist.",
+				Optional:            true,
+			},
+			"required_server_version": schema.StringAttribute{
+				MarkdownDescription: "Version constraint the Atuin server must satisfy, such as `>= 18.0`. The provider checks the version the server reports before any resource or data source uses it.",
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of requests per second sent to the Atuin API, shared by all resources and data sources. Defaults to `%v`.", atuin.DefaultRequestsPerSecond),
+				Optional:            true,
+			},
+			"burst": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of requests that may be sent to the Atuin API in a single burst. Defaults to `%d`.", atuin.DefaultBurst),
+				Optional:            true,
+			},
+			"username_prefix": schema.StringAttribute{
+				MarkdownDescription: "Prefix of the username of every `atuin_user`, unless its username already starts with it.",
+				Optional:            true,
+			},
+			"email_template": schema.StringAttribute{
+				MarkdownDescription: "Email of an `atuin_user` without one, where `" + emailTemplateUsername + "` is replaced by its full username, e.g. `" + emailTemplateUsername + "@atuin.corp.example`.",
+				Optional:            true,
+			},
+			"tracing": schema.SingleNestedAttribute{
+				MarkdownDescription: "Export OpenTelemetry traces of all Atuin API calls and resource operations. Spans never contain credentials or keys.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"otlp_endpoint": schema.StringAttribute{
+						MarkdownDescription: "URL of an OTLP/HTTP endpoint to export spans to, e.g. `http://localhost:4318`.",
+						Optional:            true,
+					},
+					"file": schema.StringAttribute{
+						MarkdownDescription: "Path of a file to append spans to, as JSON.",
+						Optional:            true,
+					},
+				},
+			},
+			"auth": schema.SingleNestedAttribute{
+				MarkdownDescription: "Credentials of an existing Atuin user. The provider logs in once, and data sources that act on the data of the user use this session when they are not given credentials themselves.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"username": schema.StringAttribute{
+						MarkdownDescription: "Username of Atuin user. May also be provided via the `ATUIN_USERNAME` environment variable.",
+						Optional:            true,
+					},
+					"password": schema.StringAttribute{
+						MarkdownDescription: "Password of Atuin user. May also be provided via the `ATUIN_PASSWORD` environment variable.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"session_token": schema.StringAttribute{
+						MarkdownDescription: "Session token of an existing login, used instead of logging in when no username and password are set. May also be provided via the `ATUIN_PROVIDER_SESSION_TOKEN` environment variable.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"use_local_session": schema.BoolAttribute{
+						MarkdownDescription: "Reuse the session of `atuin login` on this machine, and its encryption key, when no other credentials are configured. It takes precedence over the `ATUIN_PROVIDER_SESSION_TOKEN` environment variable. The `session` and `key` files are read from the Atuin data directory, honoring `ATUIN_DATA_DIR` and the `data_dir`, `session_path` and `key_path` settings of the Atuin client configuration.",
+						Optional:            true,
+					},
+				},
 			},
 		},
 	}
@@ -81,21 +149,67 @@ func (p *atuinProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		)
 	}
 
+	if config.RequiredServerVersion.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("required_server_version"),
+			"Unknown Atuin Server Version Constraint",
+			"The provider cannot check the Atuin server version as there is an unknown configuration value for the required server version. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	if config.RequestsPerSecond.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("requests_per_second"),
+			"Unknown Atuin API Rate Limit",
+			"The provider cannot create the Atuin API client as there is an unknown configuration value for the requests per second. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	if config.Burst.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("burst"),
+			"Unknown Atuin API Burst",
+			"The provider cannot create the Atuin API client as there is an unknown configuration value for the burst. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Default values to environment variables, or public Atuin endpoint, but override
-	// with Terraform configuration value if set.
+	// Default values to environment variables, the sync address of the Atuin client
+	// configuration, or public Atuin endpoint, but override with Terraform configuration
+	// value if set.
 
-	host := os.Getenv("ATUIN_HOST")
+	host, hostSource := os.Getenv("ATUIN_HOST"), "ATUIN_HOST environment variable"
 
-	if host == "" {
-		host = atuin.API_ENDPOINT
+	if !config.Host.IsNull() {
+		host, hostSource = config.Host.ValueString(), "provider configuration"
 	}
 
-	if !config.Host.IsNull() {
-		host = config.Host.ValueString()
+	if host == "" && config.Host.IsNull() {
+		// Only a missing configuration file falls back to the public Atuin API: a broken one may well have
+		// a self-hosted sync address, and the provider must not register users on the public API instead.
+		clientConfig, file, err := readAtuinClientConfig()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("host"),
+				"Unable to Read Atuin Client Configuration",
+				fmt.Sprintf("The provider cannot read the sync address from the Atuin client configuration %s: %s. "+
+					"Fix the configuration file, or set the host value in the configuration or the ATUIN_HOST environment variable.", file, err),
+			)
+			return
+		}
+		if clientConfig != nil && clientConfig.SyncAddress != "" {
+			host, hostSource = clientConfig.SyncAddress, file
+		}
+	}
+
+	if host == "" && config.Host.IsNull() {
+		host, hostSource = atuin.API_ENDPOINT, "default"
 	}
 
 	// If any of the expected configurations are missing, return
@@ -109,6 +223,73 @@ func (p *atuinProvider) Configure(ctx context.Context, req provider.ConfigureReq
 				"Set the host value in the configuration or use the atuin_HOST environment variable. "+
 				"If either is already set, ensure the value is not empty.",
 		)
+	} else if hostURL, err := atuin.ParseHost(host); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("host"),
+			"Invalid Atuin API Host",
+			fmt.Sprintf("The Atuin API host from the %s is not a valid URL, such as https://api.atuin.sh or https://tools.example.com/atuin: %s", hostSource, err),
+		)
+	} else {
+		host = hostURL.String()
+	}
+
+	var serverVersionConstraints version.Constraints
+	if !config.RequiredServerVersion.IsNull() {
+		constraints, err := version.NewConstraint(config.RequiredServerVersion.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("required_server_version"),
+				"Invalid Atuin Server Version Constraint",
+				fmt.Sprintf("The required server version must be a version constraint, such as \">= 18.0\": %s", err),
+			)
+		}
+		serverVersionConstraints = constraints
+	}
+
+	for name, value := range map[string]types.String{"username_prefix": config.UsernamePrefix, "email_template": config.EmailTemplate} {
+		if value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Unknown Atuin Naming Convention",
+				"The provider cannot apply the naming conventions of Atuin users as there is an unknown configuration value for the "+name+". "+
+					"Either target apply the source of the value first or set the value statically in the configuration.",
+			)
+		}
+	}
+
+	naming := atuinNaming{usernamePrefix: config.UsernamePrefix.ValueString(), emailTemplate: config.EmailTemplate.ValueString()}
+	prefixErr, templateErr := validateNaming(naming.usernamePrefix, naming.emailTemplate)
+	if prefixErr != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("username_prefix"), "Invalid Username Prefix", prefixErr.Error())
+	}
+	if templateErr != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("email_template"), "Invalid Email Template", templateErr.Error())
+	}
+
+	requestsPerSecond := atuin.DefaultRequestsPerSecond
+	if !config.RequestsPerSecond.IsNull() {
+		requestsPerSecond = config.RequestsPerSecond.ValueFloat64()
+	}
+
+	if requestsPerSecond <= 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("requests_per_second"),
+			"Invalid Atuin API Rate Limit",
+			fmt.Sprintf("The requests per second must be greater than zero, got: %v.", requestsPerSecond),
+		)
+	}
+
+	burst := int64(atuin.DefaultBurst)
+	if !config.Burst.IsNull() {
+		burst = config.Burst.ValueInt64()
+	}
+
+	if burst < 1 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("burst"),
+			"Invalid Atuin API Burst",
+			fmt.Sprintf("The burst must be at least 1, got: %d.", burst),
+		)
 	}
 
 	if resp.Diagnostics.HasError() {
@@ -116,15 +297,48 @@ func (p *atuinProvider) Configure(ctx context.Context, req provider.ConfigureReq
 	}
 
 	ctx = tflog.SetField(ctx, "atuin_host", host)
+	ctx = tflog.SetField(ctx, "atuin_host_source", hostSource)
+	ctx = tflog.SetField(ctx, "atuin_requests_per_second", requestsPerSecond)
+	ctx = tflog.SetField(ctx, "atuin_burst", burst)
 
 	tflog.Debug(ctx, "Creating atuin client")
 
+	opts := []atuin.Option{atuin.WithRateLimit(requestsPerSecond, int(burst))}
+
+	if config.Tracing != nil {
+		tp, shutdown, err := newTracerProvider(ctx, config.Tracing, p.version)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tracing"),
+				"Unable to Configure Tracing",
+				fmt.Sprintf("The provider cannot configure OpenTelemetry tracing: %s", err),
+			)
+			return
+		}
+
+		registerShutdown(shutdown)
+		opts = append(opts, atuin.WithTracerProvider(tp))
+	}
+
 	// Create a new atuin client using the configuration values
-	client := atuin.NewAtuinClient(host)
+	client := atuin.NewAtuinClient(host, opts...)
+
+	if serverVersionConstraints != nil {
+		resp.Diagnostics.Append(checkServerVersion(ctx, client, serverVersionConstraints)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(configureAuth(ctx, client, config.Auth)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	// Make the atuin client available during DataSource and Resource
-	// type Configure methods.
-	resp.ResourceData = client
+	// type Configure methods. Both share the same client, and thereby the same rate limiter.
+	resp.DataSourceData = client
+	resp.ResourceData = &atuinResourceData{client: client, naming: naming}
 
 	tflog.Info(ctx, "Configured Atuin client", map[string]any{"success": true})
 }
@@ -138,5 +352,8 @@ func (p *atuinProvider) Resources(_ context.Context) []func() resource.Resource
 
 // DataSources defines the data sources implemented in the provider.
 func (p *atuinProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewAtuinActivity,
+		NewAtuinStoreVerification,
+	}
 }
@@ -1,10 +1,15 @@
 package provider
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	fwprovider "github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/stretchr/testify/assert"
 )
 
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
@@ -20,3 +25,34 @@ func testAccPreCheck(t *testing.T) {
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+func TestProviderConfigureMalformedClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ATUIN_CONFIG_DIR", dir)
+	t.Setenv("ATUIN_HOST", "")
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("sync_address = \"https://atuin.example.com\"\nsync_address = "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New("test")()
+
+	schemaResp := &fwprovider.SchemaResponse{}
+	p.Schema(t.Context(), fwprovider.SchemaRequest{}, schemaResp)
+
+	// The provider configuration has no plan, but a plan can be set from the model
+	empty := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := empty.Set(t.Context(), &atuinProviderModel{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: empty.Raw}
+
+	// A broken configuration file must not fall back to the public Atuin API
+	resp := &fwprovider.ConfigureResponse{}
+	p.Configure(t.Context(), fwprovider.ConfigureRequest{Config: config}, resp)
+
+	if assert.Len(t, resp.Diagnostics.Errors(), 1) {
+		assert.Equal(t, "Unable to Read Atuin Client Configuration", resp.Diagnostics.Errors()[0].Summary())
+	}
+	assert.Nil(t, resp.ResourceData)
+}
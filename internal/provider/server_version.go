@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// checkServerVersion fails when the version the Atuin server reports does not satisfy the constraints.
+func checkServerVersion(ctx context.Context, client *atuin.AtuinClient, constraints version.Constraints) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	info, err := client.ServerInfo(ctx)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("required_server_version"),
+			"Unable to Determine Atuin Server Version",
+			fmt.Sprintf("The provider cannot check the required server version, as the Atuin server did not report its version: %s", err),
+		)
+		return diags
+	}
+
+	serverVersion, err := version.NewVersion(info.Version)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("required_server_version"),
+			"Unable to Determine Atuin Server Version",
+			fmt.Sprintf("The Atuin server reported an invalid version %q: %s", info.Version, err),
+		)
+		return diags
+	}
+
+	tflog.Debug(ctx, "Checking Atuin server version", map[string]any{"atuin_server_version": serverVersion.String()})
+
+	if !constraints.Check(serverVersion) {
+		diags.AddAttributeError(
+			path.Root("required_server_version"),
+			"Unsupported Atuin Server Version",
+			fmt.Sprintf("The Atuin server runs version %s, which does not satisfy the required server version %q. "+
+				"Upgrade the server, or relax the required_server_version of the provider.", serverVersion, constraints),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckServerVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"homage": "An homage to an homage to fish", "version": "18.4.0"}`))
+	}))
+	defer server.Close()
+
+	client := atuin.NewAtuinClient(server.URL)
+
+	for constraint, ok := range map[string]bool{
+		">= 18.0":         true,
+		"~> 18.3":         true,
+		">= 18.0, < 18.4": false,
+		">= 19":           false,
+	} {
+		diags := checkServerVersion(t.Context(), client, version.MustConstraints(version.NewConstraint(constraint)))
+		assert.Equal(t, !ok, diags.HasError(), constraint)
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	atuin "terraform-provider-atuin/internal/atuin_client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "terraform-provider-atuin/internal/provider"
+
+// atuinTracingModel maps the tracing configuration of the provider.
+type atuinTracingModel struct {
+	OTLPEndpoint types.String `tfsdk:"otlp_endpoint"`
+	File         types.String `tfsdk:"file"`
+}
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownFuncs []func(context.Context) error
+)
+
+// Shutdown flushes and shuts down the tracer providers created by Configure, and closes their trace
+// files. It is called once the provider server stops serving.
+func Shutdown(ctx context.Context) error {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+
+	var errs []error
+	for _, shutdown := range shutdownFuncs {
+		errs = append(errs, shutdown(ctx))
+	}
+	shutdownFuncs = nil
+
+	return errors.Join(errs...)
+}
+
+// registerShutdown registers a function to call on Shutdown.
+func registerShutdown(shutdown func(context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+
+	shutdownFuncs = append(shutdownFuncs, shutdown)
+}
+
+// newTracerProvider creates a tracer provider that exports spans to the configured OTLP endpoint and/or
+// file, and a function that shuts it down and closes the file. Spans are exported synchronously, so
+// they are not lost when Terraform kills the provider before Shutdown is called.
+func newTracerProvider(ctx context.Context, config *atuinTracingModel, version string) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(sdkresource.NewSchemaless(
+			attribute.String("service.name", "terraform-provider-atuin"),
+			attribute.String("service.version", version),
+		)),
+	}
+
+	if !config.OTLPEndpoint.IsNull() {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.OTLPEndpoint.ValueString()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	}
+
+	var file *os.File
+	if !config.File.IsNull() {
+		f, err := os.OpenFile(config.File.ValueString(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to open trace file: %w", err)
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("unable to create file exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+		file = f
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	shutdown := func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}
+
+	return tp, shutdown, nil
+}
+
+// startSpan starts a span for a resource or data source operation, using the tracer provider of the client.
+func startSpan(ctx context.Context, client *atuin.AtuinClient, name string) (context.Context, trace.Span) {
+	tracer := otel.Tracer(tracerName)
+	if client != nil {
+		tracer = client.Tracer()
+	}
+
+	return tracer.Start(ctx, name)
+}
+
+// endSpan ends the span, and marks it as failed if the operation returned errors.
+func endSpan(span trace.Span, diags diag.Diagnostics) {
+	if diags.HasError() {
+		errs := diags.Errors()
+		span.SetStatus(codes.Error, errs[0].Summary()+": "+errs[0].Detail())
+	}
+
+	span.End()
+}
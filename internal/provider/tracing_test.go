@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTracerProviderFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	tp, shutdown, err := newTracerProvider(t.Context(), &atuinTracingModel{OTLPEndpoint: types.StringNull(), File: types.StringValue(file)}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tp.Tracer(tracerName).Start(t.Context(), "atuin_user.Create")
+	span.End()
+
+	registerShutdown(shutdown)
+	if err := Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider is shut down, so later spans are dropped
+	_, span = tp.Tracer(tracerName).Start(t.Context(), "atuin_user.Read")
+	span.End()
+
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(contents), `"Name":"atuin_user.Create"`)
+	assert.Contains(t, string(contents), "terraform-provider-atuin")
+	assert.NotContains(t, string(contents), "atuin_user.Read")
+}
+
+func TestShutdown(t *testing.T) {
+	var calls int
+	registerShutdown(func(context.Context) error { calls++; return nil })
+	registerShutdown(func(context.Context) error { calls++; return errors.New("file already closed") })
+
+	assert.EqualError(t, Shutdown(t.Context()), "file already closed")
+	assert.Equal(t, 2, calls)
+
+	// Every function is only called once
+	assert.NoError(t, Shutdown(t.Context()))
+	assert.Equal(t, 2, calls)
+}
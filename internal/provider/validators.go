@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+
+	"github.com/ccojocar/zxcvbn-go"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// usernameRegexp matches the usernames the Atuin server accepts, like atuin.ValidateUsername.
+var usernameRegexp = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// validEmail checks that the value is an email address with RFC 5322 syntax, without a display name.
+func validEmail() validator.String {
+	return emailValidator{}
+}
+
+type emailValidator struct{}
+
+func (v emailValidator) Description(_ context.Context) string {
+	return "value must be an email address"
+}
+
+func (v emailValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v emailValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	address, err := mail.ParseAddress(value)
+	if err == nil && address.Address != value {
+		err = fmt.Errorf("expected only the address %q", address.Address)
+	}
+
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Email",
+			fmt.Sprintf("The email %q is not a valid email address: %s", value, err),
+		)
+	}
+}
+
+// passwordStrength estimates the strength of a password, from 0 for a password that is guessed in
+// seconds to 4 for one that cannot be guessed in years. Guesses based on the user inputs, like the
+// username and email, weaken the password.
+func passwordStrength(password string, userInputs ...string) int64 {
+	return int64(zxcvbn.PasswordStrength(password, userInputs).Score)
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailValidator(t *testing.T) {
+	tests := []struct {
+		email       types.String
+		expectError bool
+	}{
+		{types.StringValue("rincewind@uu.am"), false},
+		{types.StringValue("the.librarian+ook@unseen-university.am"), false},
+		{types.StringNull(), false},
+		{types.StringUnknown(), false},
+		{types.StringValue(""), true},
+		{types.StringValue("rincewind"), true},
+		{types.StringValue("rincewind@"), true},
+		{types.StringValue("rincewind @uu.am"), true},
+		{types.StringValue("Rincewind <rincewind@uu.am>"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email.String(), func(t *testing.T) {
+			resp := &validator.StringResponse{}
+			validEmail().ValidateString(t.Context(), validator.StringRequest{Path: path.Root("email"), ConfigValue: tt.email}, resp)
+			assert.Equal(t, tt.expectError, resp.Diagnostics.HasError(), resp.Diagnostics)
+		})
+	}
+}
+
+func TestPasswordStrength(t *testing.T) {
+	assert.Equal(t, int64(0), passwordStrength("swordfish"))
+	assert.Equal(t, int64(4), passwordStrength("correct horse battery staple"))
+	assert.Less(t, passwordStrength("rincewind-uu-am", "rincewind", "rincewind@uu.am"), passwordStrength("rincewind-uu-am"))
+}
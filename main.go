@@ -38,6 +38,12 @@ func main() {
 	}
 
 	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+
+	// Flush the spans and close the trace files of the provider, before exiting
+	if shutdownErr := provider.Shutdown(context.Background()); shutdownErr != nil {
+		log.Printf("unable to shut down tracing: %s", shutdownErr)
+	}
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}